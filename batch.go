@@ -0,0 +1,212 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// defaultConfidenceLevel is the confidence level of the intervals that a Batch reports if none is configured.
+const defaultConfidenceLevel = 0.95
+
+// region Batch ////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Batch executes many seeded runs of the same scenario (Monte Carlo) and aggregates their outcomes. Every run uses its
+// own Network with a SimulatedClock and a source of randomness that is seeded with Seed+i (i being the index of the
+// run), so a Batch with the same Seed draws the same random numbers again.
+type Batch struct {
+	// MetastabilityBreakingThreshold is the threshold of the Networks that are created for the runs.
+	MetastabilityBreakingThreshold time.Duration
+
+	// Scenario adds the Voters of the scenario to the Network.
+	Scenario func(network *Network)
+
+	// Runs is the number of runs.
+	Runs int
+
+	// Seed is the seed of the first run.
+	Seed int64
+
+	// BranchIDs are the conflicting Branches that the Voters need to agree on.
+	BranchIDs []BranchID
+
+	// Timeout is the simulated time after which a run is considered as not resolved.
+	Timeout time.Duration
+
+	// ConfidenceLevel is the confidence level of the reported intervals (0 defaults to 95%).
+	ConfidenceLevel float64
+
+	// Parallelism is the number of runs that are executed at the same time (0 defaults to the number of CPUs).
+	Parallelism int
+}
+
+// Run executes all runs of the Batch and returns the aggregated result.
+func (b *Batch) Run() (result *BatchResult, err error) {
+	if b.Runs <= 0 {
+		return nil, fmt.Errorf("batch needs at least one run but has %d: %w", b.Runs, ErrInvalidParameter)
+	}
+
+	confidenceLevel := b.ConfidenceLevel
+	if confidenceLevel == 0 {
+		confidenceLevel = defaultConfidenceLevel
+	}
+	if confidenceLevel <= 0 || confidenceLevel >= 1 {
+		return nil, fmt.Errorf("ConfidenceLevel of %f must be in (0, 1): %w", confidenceLevel, ErrInvalidParameter)
+	}
+
+	outcomes := make([]*runOutcome, b.Runs)
+	if err = runInParallel(b.Runs, b.Parallelism, func(run int) (runErr error) {
+		outcomes[run], runErr = b.run(b.Seed + int64(run))
+
+		return runErr
+	}); err != nil {
+		return nil, err
+	}
+
+	return newBatchResult(outcomes, confidenceLevel), nil
+}
+
+// run executes the scenario once with the given seed.
+func (b *Batch) run(seed int64) (outcome *runOutcome, err error) {
+	network := NewNetwork(b.MetastabilityBreakingThreshold)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(seed)
+	b.Scenario(network)
+
+	return simulate(network, b.Timeout, b.BranchIDs...)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region BatchResult //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// BatchResult contains the aggregated outcome of the runs of a Batch.
+type BatchResult struct {
+	// Runs is the number of executed runs.
+	Runs int
+
+	// ResolvedRuns is the number of runs that resolved the conflict before the timeout.
+	ResolvedRuns int
+
+	// ConfidenceLevel is the confidence level of the reported intervals.
+	ConfidenceLevel float64
+
+	// ResolutionTimes are the resolution times of the resolved runs in ascending order.
+	ResolutionTimes []time.Duration
+
+	// MeanOpinionFlips is the mean number of opinion flips of the HonestVoters per run.
+	MeanOpinionFlips float64
+}
+
+// newBatchResult aggregates the given outcomes.
+func newBatchResult(outcomes []*runOutcome, confidenceLevel float64) (result *BatchResult) {
+	result = &BatchResult{
+		Runs:             len(outcomes),
+		ConfidenceLevel:  confidenceLevel,
+		ResolutionTimes:  sortedResolutionTimes(outcomes),
+		MeanOpinionFlips: meanOpinionFlips(outcomes),
+	}
+	result.ResolvedRuns = len(result.ResolutionTimes)
+
+	return result
+}
+
+// ResolutionProbability returns the share of the runs that resolved the conflict together with its confidence interval.
+func (b *BatchResult) ResolutionProbability() (probability, lowerBound, upperBound float64) {
+	return b.probability(b.ResolvedRuns)
+}
+
+// ResolvedWithin returns the share of the runs that resolved the conflict within the given time together with its
+// confidence interval.
+func (b *BatchResult) ResolvedWithin(duration time.Duration) (probability, lowerBound, upperBound float64) {
+	resolvedRuns := 0
+	for _, resolutionTime := range b.ResolutionTimes {
+		if resolutionTime > duration {
+			break
+		}
+
+		resolvedRuns++
+	}
+
+	return b.probability(resolvedRuns)
+}
+
+// Percentile returns the given percentile of the resolution times of the resolved runs.
+func (b *BatchResult) Percentile(p float64) time.Duration {
+	return percentile(b.ResolutionTimes, p)
+}
+
+// MeanResolutionTime returns the mean resolution time of the resolved runs.
+func (b *BatchResult) MeanResolutionTime() time.Duration {
+	if len(b.ResolutionTimes) == 0 {
+		return 0
+	}
+
+	var totalResolutionTime time.Duration
+	for _, resolutionTime := range b.ResolutionTimes {
+		totalResolutionTime += resolutionTime
+	}
+
+	return totalResolutionTime / time.Duration(len(b.ResolutionTimes))
+}
+
+// probability returns the share of the given number of successful runs together with its Wilson score interval.
+func (b *BatchResult) probability(successfulRuns int) (probability, lowerBound, upperBound float64) {
+	lowerBound, upperBound = wilsonInterval(successfulRuns, b.Runs, b.ConfidenceLevel)
+
+	return float64(successfulRuns) / float64(b.Runs), lowerBound, upperBound
+}
+
+func (b *BatchResult) String() string {
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"Metric", "Value"})
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+	probability, lowerBound, upperBound := b.ResolutionProbability()
+	table.AppendBulk([][]string{
+		{"Runs", fmt.Sprintf("%d", b.Runs)},
+		{"ResolutionProbability", fmt.Sprintf("%0.3f [%0.3f, %0.3f] (%0.0f%% CI)", probability, lowerBound, upperBound, b.ConfidenceLevel*100)},
+		{"MeanResolutionTime", b.MeanResolutionTime().String()},
+		{"MinResolutionTime", b.Percentile(0).String()},
+		{"P50ResolutionTime", b.Percentile(0.5).String()},
+		{"P90ResolutionTime", b.Percentile(0.9).String()},
+		{"P95ResolutionTime", b.Percentile(0.95).String()},
+		{"P99ResolutionTime", b.Percentile(0.99).String()},
+		{"MaxResolutionTime", b.Percentile(1).String()},
+		{"MeanOpinionFlips", fmt.Sprintf("%0.2f", b.MeanOpinionFlips)},
+	})
+
+	table.Render()
+
+	return buf.String()
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region wilsonInterval ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// wilsonInterval returns the Wilson score interval of a binomial proportion with the given number of successes and
+// trials at the given confidence level.
+func wilsonInterval(successes, trials int, confidenceLevel float64) (lowerBound, upperBound float64) {
+	if trials == 0 {
+		return 0, 1
+	}
+
+	z := math.Sqrt2 * math.Erfinv(confidenceLevel)
+	n := float64(trials)
+	p := float64(successes) / n
+
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,106 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch_MinorityVoter(t *testing.T) {
+	batch := &Batch{
+		MetastabilityBreakingThreshold: 1 * time.Second,
+		Scenario: func(network *Network) {
+			network.PerceptionNoise = PerceptionNoise{MaxWeightDeviation: 0.05, MaxUpdateDelay: 200 * time.Millisecond}
+			network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+			network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+		},
+		Runs:      500,
+		Seed:      1,
+		BranchIDs: []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:   60 * time.Second,
+	}
+
+	result, err := batch.Run()
+	require.NoError(t, err)
+	assert.Equal(t, 500, result.Runs)
+
+	_, lowerBound, _ := result.ResolvedWithin(1500 * time.Millisecond)
+	assert.GreaterOrEqual(t, lowerBound, 0.95, "the metastable state should be broken within 1.5 seconds")
+
+	t.Logf("\n%s", result)
+
+	// the same runs without the metastability breaker
+	batch.MetastabilityBreakingThreshold = 0
+	controlResult, err := batch.Run()
+	require.NoError(t, err)
+
+	_, _, controlUpperBound := controlResult.ResolvedWithin(1500 * time.Millisecond)
+	assert.Less(t, controlUpperBound, lowerBound, "the runs without the metastability breaker should resolve less often within 1.5 seconds")
+	assert.Greater(t, controlResult.MeanOpinionFlips, result.MeanOpinionFlips, "the runs without the metastability breaker should flip more often")
+
+	t.Logf("\n%s", controlResult)
+}
+
+func TestBatch_Reproducible(t *testing.T) {
+	batch := &Batch{
+		MetastabilityBreakingThreshold: 2 * time.Second,
+		Scenario: func(network *Network) {
+			network.PerceptionNoise = PerceptionNoise{MaxWeightDeviation: 0.1, MaxUpdateDelay: 300 * time.Millisecond}
+			network.AddVoters(6, NewHonestVoter, FixedWeight(0.08))
+			network.AddVoters(2, NewNoisyHonestVoter(0.2), FixedWeight(0.08))
+			network.AddVoters(1, NewRandomVoter, FixedWeight(0.12))
+			network.AddVoters(1, NewMinorityVoter, FixedWeight(0.12))
+			network.AddVoters(1, NewLowerHashVoter, FixedWeight(0.12))
+		},
+		Runs:      50,
+		Seed:      42,
+		BranchIDs: []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:   10 * time.Second,
+	}
+
+	firstResult, err := batch.Run()
+	require.NoError(t, err)
+	secondResult, err := batch.Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, firstResult, secondResult, "runs with the same seeds should have the same outcome")
+	assert.NotZero(t, firstResult.ResolvedRuns)
+	assert.NotZero(t, firstResult.MeanOpinionFlips)
+
+	batch.Runs = 0
+	_, err = batch.Run()
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestWilsonInterval(t *testing.T) {
+	lowerBound, upperBound := wilsonInterval(8, 10, 0.95)
+	assert.InDelta(t, 0.490, lowerBound, 1e-3)
+	assert.InDelta(t, 0.943, upperBound, 1e-3)
+
+	lowerBound, upperBound = wilsonInterval(0, 10, 0.95)
+	assert.Equal(t, float64(0), lowerBound)
+	assert.InDelta(t, 0.278, upperBound, 1e-3)
+}
+
+// runAttackBatch runs the given amount of seeded runs of the given attack scenario with noisy perceptions (a weight
+// deviation of 0.05 and update delays of up to 200ms) on the Branches 1 and 2.
+func runAttackBatch(t *testing.T, metastabilityBreakingThreshold time.Duration, runs int, scenario func(network *Network)) (result *BatchResult) {
+	batch := &Batch{
+		MetastabilityBreakingThreshold: metastabilityBreakingThreshold,
+		Scenario: func(network *Network) {
+			network.PerceptionNoise = PerceptionNoise{MaxWeightDeviation: 0.05, MaxUpdateDelay: 200 * time.Millisecond}
+			scenario(network)
+		},
+		Runs:      runs,
+		Seed:      1,
+		BranchIDs: []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:   30 * time.Second,
+	}
+
+	result, err := batch.Run()
+	require.NoError(t, err)
+
+	return result
+}
@@ -0,0 +1,525 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/iotaledger/hive.go/types"
+)
+
+// region ChartFormat //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ChartFormat is the image format of a chart.
+type ChartFormat string
+
+const (
+	// ChartFormatSVG renders charts as scalable vector graphics.
+	ChartFormatSVG ChartFormat = "svg"
+
+	// ChartFormatPNG renders charts as raster images.
+	ChartFormatPNG ChartFormat = "png"
+)
+
+// newCanvas returns a canvas of the given size that renders in the ChartFormat.
+func (c ChartFormat) newCanvas(width, height int) (canvas canvas, err error) {
+	switch c {
+	case ChartFormatSVG:
+		return newSVGCanvas(width, height), nil
+	case ChartFormatPNG:
+		return newRasterCanvas(width, height), nil
+	default:
+		return nil, fmt.Errorf("unknown chart format '%s': %w", c, ErrInvalidParameter)
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region Charts ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// WriteWeightChart draws the weights of the Branches per voter type over time together with the weight gap between the
+// competing Branches and the breaker threshold. The metastability breaker favors the lower hash whenever the weight gap
+// line is below the breaker threshold line.
+func (t TimeSeries) WriteWeightChart(writer io.Writer, format ChartFormat) (err error) {
+	if len(t) == 0 {
+		return fmt.Errorf("time series has no samples: %w", ErrInvalidParameter)
+	}
+
+	const width, height = 960, 540
+	canvas, err := format.newCanvas(width, height)
+	if err != nil {
+		return err
+	}
+
+	weightColumns := t.weightColumns()
+	plot := plotArea{left: 70, top: 40, right: width - 290, bottom: height - 50, maxX: t.duration(), maxY: 0.25}
+	for _, sample := range t {
+		for _, weightColumn := range weightColumns {
+			plot.maxY = math.Max(plot.maxY, sample.BranchWeights[weightColumn.voterType][weightColumn.branchID])
+		}
+		plot.maxY = math.Max(plot.maxY, math.Max(sample.WeightGap, sample.BreakerThreshold))
+	}
+	plot.maxY = math.Ceil(plot.maxY*4) / 4
+
+	canvas.Text(width/2, 24, "Branch weights over time", textAnchorMiddle, chartForeground)
+	plot.drawAxes(canvas, "time [s]", "weight")
+
+	legend := chartLegend{x: plot.right + 20, y: plot.top}
+	for i, weightColumn := range weightColumns {
+		points := make([]chartPoint, len(t))
+		for j, sample := range t {
+			points[j] = plot.point(sample.Time.Seconds(), sample.BranchWeights[weightColumn.voterType][weightColumn.branchID])
+		}
+
+		seriesColor := chartPalette[i%len(chartPalette)]
+		canvas.Line(points, seriesColor, false)
+		legend.line(canvas, fmt.Sprintf("%s, branch %d", weightColumn.voterType, weightColumn.branchID), seriesColor, false)
+	}
+
+	weightGap := make([]chartPoint, len(t))
+	breakerThreshold := make([]chartPoint, len(t))
+	for i, sample := range t {
+		weightGap[i] = plot.point(sample.Time.Seconds(), sample.WeightGap)
+		breakerThreshold[i] = plot.point(sample.Time.Seconds(), sample.BreakerThreshold)
+	}
+	canvas.Line(weightGap, chartMuted, false)
+	legend.line(canvas, "weight gap", chartMuted, false)
+	canvas.Line(breakerThreshold, chartForeground, true)
+	legend.line(canvas, "breaker threshold", chartForeground, true)
+
+	return canvas.Encode(writer)
+}
+
+// WriteOpinionHeatmap draws the opinions of the Voters over time with one row per Voter and one column per sample. The
+// color of a cell is the Branch that the Voter last voted for, so every opinion change of a Voter shows up as a change
+// of color in its row.
+func (t TimeSeries) WriteOpinionHeatmap(writer io.Writer, format ChartFormat) (err error) {
+	if len(t) == 0 {
+		return fmt.Errorf("time series has no samples: %w", ErrInvalidParameter)
+	}
+
+	voterTypes := make(map[VoterID]string)
+	branchIDs := make(BranchIDs)
+	for _, sample := range t {
+		for voterID, opinion := range sample.Opinions {
+			voterTypes[voterID] = opinion.VoterType
+			branchIDs[opinion.BranchID] = types.Void
+		}
+	}
+	voterIDs := make([]VoterID, 0, len(voterTypes))
+	for voterID := range voterTypes {
+		voterIDs = append(voterIDs, voterID)
+	}
+	sort.Slice(voterIDs, func(i, j int) bool { return voterIDs[i] < voterIDs[j] })
+
+	rowHeight := 16
+	if len(voterIDs) > 25 {
+		rowHeight = int(math.Max(4, math.Floor(400/float64(len(voterIDs)))))
+	}
+
+	const width = 960
+	height := 40 + rowHeight*len(voterIDs) + 50
+	canvas, err := format.newCanvas(width, height)
+	if err != nil {
+		return err
+	}
+
+	// a sample stands for the time until the next sample, so the last sample needs its own column as well
+	columnDuration := t.duration() / float64(len(t))
+	if len(t) > 1 {
+		columnDuration = t.duration() / float64(len(t)-1)
+	}
+	plot := plotArea{left: 170, top: 40, right: width - 130, bottom: float64(height - 50), maxX: t.duration() + columnDuration, maxY: float64(len(voterIDs))}
+
+	canvas.Text(width/2, 24, "Opinions per voter over time", textAnchorMiddle, chartForeground)
+	branchColors := make(map[BranchID]color.RGBA)
+	legend := chartLegend{x: plot.right + 20, y: plot.top}
+	for i, branchID := range branchIDs.Sorted() {
+		branchColors[branchID] = chartPalette[i%len(chartPalette)]
+		legend.box(canvas, fmt.Sprintf("branch %d", branchID), branchColors[branchID])
+	}
+	legend.box(canvas, "no vote", chartBackground)
+
+	for row, voterID := range voterIDs {
+		y := plot.top + float64(row*rowHeight)
+		if rowHeight >= 10 {
+			canvas.Text(plot.left-8, y+float64(rowHeight)-4, fmt.Sprintf("%s %d", voterTypes[voterID], voterID), textAnchorEnd, chartForeground)
+		}
+
+		for _, sample := range t {
+			cellColor := chartBackground
+			if opinion, exists := sample.Opinions[voterID]; exists {
+				cellColor = branchColors[opinion.BranchID]
+			}
+
+			x := plot.point(sample.Time.Seconds(), 0).x
+			canvas.Rect(x, y, plot.point(sample.Time.Seconds()+columnDuration, 0).x-x, float64(rowHeight), cellColor)
+		}
+	}
+	plot.drawXAxis(canvas, "time [s]")
+
+	return canvas.Encode(writer)
+}
+
+// duration returns the time of the last sample in seconds (at least 1 to have a non-empty time axis).
+func (t TimeSeries) duration() float64 {
+	if duration := t[len(t)-1].Time.Seconds(); duration > 0 {
+		return duration
+	}
+
+	return 1
+}
+
+// chartPalette contains the colors of the data series of the charts.
+var chartPalette = []color.RGBA{
+	{R: 0x4e, G: 0x79, B: 0xa7, A: 0xff},
+	{R: 0xf2, G: 0x8e, B: 0x2b, A: 0xff},
+	{R: 0xe1, G: 0x57, B: 0x59, A: 0xff},
+	{R: 0x76, G: 0xb7, B: 0xb2, A: 0xff},
+	{R: 0x59, G: 0xa1, B: 0x4f, A: 0xff},
+	{R: 0xed, G: 0xc9, B: 0x48, A: 0xff},
+	{R: 0xb0, G: 0x7a, B: 0xa1, A: 0xff},
+	{R: 0xff, G: 0x9d, B: 0xa7, A: 0xff},
+	{R: 0x9c, G: 0x75, B: 0x5f, A: 0xff},
+}
+
+var (
+	// chartForeground is the color of the axes, the labels and the breaker threshold.
+	chartForeground = color.RGBA{R: 0x22, G: 0x22, B: 0x22, A: 0xff}
+
+	// chartMuted is the color of the weight gap.
+	chartMuted = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff}
+
+	// chartBackground is the color of the grid and of the empty cells of the heatmap.
+	chartBackground = color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+)
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region plotArea /////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// plotArea maps the values of a chart (starting at 0 on both axes) to the pixels of the rectangle that contains the
+// data.
+type plotArea struct {
+	left, top, right, bottom float64
+	maxX, maxY               float64
+}
+
+// point returns the pixel of the given values.
+func (p plotArea) point(x, y float64) chartPoint {
+	return chartPoint{
+		x: p.left + x/p.maxX*(p.right-p.left),
+		y: p.bottom - y/p.maxY*(p.bottom-p.top),
+	}
+}
+
+// drawAxes draws both axes with their ticks, a horizontal grid and the given axis labels.
+func (p plotArea) drawAxes(canvas canvas, xLabel, yLabel string) {
+	yStep := niceStep(p.maxY)
+	for y := 0.0; y <= p.maxY+yStep/1e6; y += yStep {
+		point := p.point(0, y)
+		canvas.Line([]chartPoint{point, {x: p.right, y: point.y}}, chartBackground, false)
+		canvas.Text(point.x-8, point.y+4, formatFloat(math.Round(y*1e6)/1e6), textAnchorEnd, chartForeground)
+	}
+	canvas.Line([]chartPoint{{x: p.left, y: p.top}, {x: p.left, y: p.bottom}}, chartForeground, false)
+	canvas.Text(p.left, p.top-8, yLabel, textAnchorMiddle, chartForeground)
+
+	p.drawXAxis(canvas, xLabel)
+}
+
+// drawXAxis draws the horizontal axis with its ticks and the given label.
+func (p plotArea) drawXAxis(canvas canvas, label string) {
+	canvas.Line([]chartPoint{{x: p.left, y: p.bottom}, {x: p.right, y: p.bottom}}, chartForeground, false)
+
+	xStep := niceStep(p.maxX)
+	for x := 0.0; x <= p.maxX+xStep/1e6; x += xStep {
+		point := p.point(x, 0)
+		canvas.Line([]chartPoint{point, {x: point.x, y: point.y + 5}}, chartForeground, false)
+		canvas.Text(point.x, point.y+20, formatFloat(math.Round(x*1e6)/1e6), textAnchorMiddle, chartForeground)
+	}
+	canvas.Text((p.left+p.right)/2, p.bottom+40, label, textAnchorMiddle, chartForeground)
+}
+
+// niceStep returns a step of 1, 2 or 5 times a power of ten that divides the given range into at most 8 ticks.
+func niceStep(valueRange float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(valueRange/8)))
+	for _, factor := range []float64{1, 2, 5, 10} {
+		if valueRange/(factor*magnitude) <= 8 {
+			return factor * magnitude
+		}
+	}
+
+	return 10 * magnitude
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region chartLegend //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// chartLegend draws the entries of a legend below each other.
+type chartLegend struct {
+	x, y float64
+}
+
+// line adds an entry with a line in the given color.
+func (c *chartLegend) line(canvas canvas, label string, lineColor color.RGBA, dashed bool) {
+	canvas.Line([]chartPoint{{x: c.x, y: c.y + 6}, {x: c.x + 24, y: c.y + 6}}, lineColor, dashed)
+	canvas.Text(c.x+32, c.y+10, label, textAnchorStart, chartForeground)
+	c.y += 20
+}
+
+// box adds an entry with a box in the given color.
+func (c *chartLegend) box(canvas canvas, label string, boxColor color.RGBA) {
+	canvas.Rect(c.x, c.y, 12, 12, boxColor)
+	canvas.Text(c.x+20, c.y+10, label, textAnchorStart, chartForeground)
+	c.y += 20
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region canvas ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// canvas is the drawing surface of the charts that is implemented once per ChartFormat.
+type canvas interface {
+	// Rect fills a rectangle.
+	Rect(x, y, width, height float64, fill color.RGBA)
+
+	// Line strokes a line through the given points.
+	Line(points []chartPoint, stroke color.RGBA, dashed bool)
+
+	// Text writes a single line of text with its baseline at the given y coordinate.
+	Text(x, y float64, text string, anchor textAnchor, fill color.RGBA)
+
+	// Encode writes the drawing to the given writer.
+	Encode(writer io.Writer) error
+}
+
+// chartPoint is a pixel of a canvas.
+type chartPoint struct {
+	x, y float64
+}
+
+// textAnchor is the horizontal alignment of a text relative to its x coordinate.
+type textAnchor string
+
+const (
+	textAnchorStart  textAnchor = "start"
+	textAnchorMiddle textAnchor = "middle"
+	textAnchorEnd    textAnchor = "end"
+)
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region svgCanvas ////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// svgCanvas is a canvas that writes an SVG document.
+type svgCanvas struct {
+	width, height int
+	elements      bytes.Buffer
+}
+
+// newSVGCanvas returns an empty svgCanvas with a white background.
+func newSVGCanvas(width, height int) (canvas *svgCanvas) {
+	canvas = &svgCanvas{width: width, height: height}
+	canvas.Rect(0, 0, float64(width), float64(height), color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+
+	return canvas
+}
+
+// Rect fills a rectangle.
+func (s *svgCanvas) Rect(x, y, width, height float64, fill color.RGBA) {
+	fmt.Fprintf(&s.elements, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\"/>\n", x, y, width, height, svgColor(fill))
+}
+
+// Line strokes a line through the given points.
+func (s *svgCanvas) Line(points []chartPoint, stroke color.RGBA, dashed bool) {
+	coordinates := make([]string, len(points))
+	for i, point := range points {
+		coordinates[i] = fmt.Sprintf("%.1f,%.1f", point.x, point.y)
+	}
+
+	dashArray := ""
+	if dashed {
+		dashArray = " stroke-dasharray=\"6,4\""
+	}
+
+	fmt.Fprintf(&s.elements, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"2\"%s/>\n", strings.Join(coordinates, " "), svgColor(stroke), dashArray)
+}
+
+// Text writes a single line of text with its baseline at the given y coordinate.
+func (s *svgCanvas) Text(x, y float64, text string, anchor textAnchor, fill color.RGBA) {
+	fmt.Fprintf(&s.elements, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"%s\" fill=\"%s\">%s</text>\n", x, y, anchor, svgColor(fill), html.EscapeString(text))
+}
+
+// Encode writes the SVG document.
+func (s *svgCanvas) Encode(writer io.Writer) (err error) {
+	if _, err = fmt.Fprintf(writer, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"12\">\n", s.width, s.height, s.width, s.height); err != nil {
+		return fmt.Errorf("failed to write chart: %w", err)
+	}
+	if _, err = s.elements.WriteTo(writer); err != nil {
+		return fmt.Errorf("failed to write chart: %w", err)
+	}
+	if _, err = io.WriteString(writer, "</svg>\n"); err != nil {
+		return fmt.Errorf("failed to write chart: %w", err)
+	}
+
+	return nil
+}
+
+// svgColor returns the hex notation of the given color.
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region rasterCanvas /////////////////////////////////////////////////////////////////////////////////////////////////
+
+// rasterCanvas is a canvas that draws into an image and encodes it as PNG. The standard library does not rasterize
+// fonts, so texts are drawn with a small built-in bitmap font.
+type rasterCanvas struct {
+	image *image.RGBA
+}
+
+// newRasterCanvas returns an empty rasterCanvas with a white background.
+func newRasterCanvas(width, height int) (canvas *rasterCanvas) {
+	canvas = &rasterCanvas{image: image.NewRGBA(image.Rect(0, 0, width, height))}
+	canvas.Rect(0, 0, float64(width), float64(height), color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+
+	return canvas
+}
+
+// Rect fills a rectangle.
+func (r *rasterCanvas) Rect(x, y, width, height float64, fill color.RGBA) {
+	bounds := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+width)), int(math.Round(y+height))).Intersect(r.image.Bounds())
+	for pixelY := bounds.Min.Y; pixelY < bounds.Max.Y; pixelY++ {
+		for pixelX := bounds.Min.X; pixelX < bounds.Max.X; pixelX++ {
+			r.image.SetRGBA(pixelX, pixelY, fill)
+		}
+	}
+}
+
+// Line strokes a line through the given points with a width of 2 pixels.
+func (r *rasterCanvas) Line(points []chartPoint, stroke color.RGBA, dashed bool) {
+	step := 0
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		steps := int(math.Max(math.Abs(to.x-from.x), math.Abs(to.y-from.y)))
+		for j := 0; j <= steps; j++ {
+			step++
+			if dashed && step%10 >= 6 {
+				continue
+			}
+
+			progress := 0.0
+			if steps != 0 {
+				progress = float64(j) / float64(steps)
+			}
+			r.Rect(math.Round(from.x+(to.x-from.x)*progress)-1, math.Round(from.y+(to.y-from.y)*progress)-1, 2, 2, stroke)
+		}
+	}
+}
+
+// Text writes a single line of text with its baseline at the given y coordinate.
+func (r *rasterCanvas) Text(x, y float64, text string, anchor textAnchor, fill color.RGBA) {
+	const scale, advance = 2, 8
+
+	textWidth := float64(len([]rune(text))*advance - scale)
+	switch anchor {
+	case textAnchorMiddle:
+		x -= textWidth / 2
+	case textAnchorEnd:
+		x -= textWidth
+	}
+
+	top := y - 5*scale
+	for _, character := range strings.ToUpper(text) {
+		glyph, exists := bitmapFont[character]
+		if !exists {
+			glyph = bitmapFont['?']
+		}
+
+		for i, bit := range glyph {
+			if bit == '1' {
+				r.Rect(x+float64(i%3*scale), top+float64(i/3*scale), scale, scale, fill)
+			}
+		}
+		x += advance
+	}
+}
+
+// Encode writes the image as PNG.
+func (r *rasterCanvas) Encode(writer io.Writer) (err error) {
+	if err = png.Encode(writer, r.image); err != nil {
+		return fmt.Errorf("failed to write chart: %w", err)
+	}
+
+	return nil
+}
+
+// bitmapFont contains the glyphs of the rasterCanvas as 3x5 pixels (row by row, 1 for a set pixel). Lowercase letters
+// are drawn as uppercase letters.
+var bitmapFont = map[rune]string{
+	' ': "000000000000000",
+	'0': "111101101101111",
+	'1': "010110010010111",
+	'2': "111001111100111",
+	'3': "111001111001111",
+	'4': "101101111001001",
+	'5': "111100111001111",
+	'6': "111100111101111",
+	'7': "111001001001001",
+	'8': "111101111101111",
+	'9': "111101111001111",
+	'A': "010101111101101",
+	'B': "110101110101110",
+	'C': "011100100100011",
+	'D': "110101101101110",
+	'E': "111100110100111",
+	'F': "111100110100100",
+	'G': "011100101101011",
+	'H': "101101111101101",
+	'I': "111010010010111",
+	'J': "001001001101010",
+	'K': "101101110101101",
+	'L': "100100100100111",
+	'M': "101111111101101",
+	'N': "110101101101101",
+	'O': "010101101101010",
+	'P': "110101110100100",
+	'Q': "010101101110011",
+	'R': "110101110101101",
+	'S': "011100010001110",
+	'T': "111010010010010",
+	'U': "101101101101111",
+	'V': "101101101101010",
+	'W': "101101111111101",
+	'X': "101101010101101",
+	'Y': "101101010010010",
+	'Z': "111001010100111",
+	'.': "000000000000010",
+	',': "000000000010100",
+	':': "000010000010000",
+	'-': "000000111000000",
+	'+': "000010111010000",
+	'_': "000000000000111",
+	'=': "000111000111000",
+	'/': "001001010100100",
+	'(': "001010010010001",
+	')': "100010010010100",
+	'[': "011010010010011",
+	']': "110010010010110",
+	'<': "001010100010001",
+	'>': "100010001010100",
+	'%': "101001010100101",
+	'?': "111001010000010",
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
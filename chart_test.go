@@ -0,0 +1,100 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSeries_WriteWeightChart(t *testing.T) {
+	timeSeries := chartTimeSeries()
+
+	var svg bytes.Buffer
+	require.NoError(t, timeSeries.WriteWeightChart(&svg, ChartFormatSVG))
+	assertWellFormedXML(t, svg.Bytes())
+	assert.Contains(t, svg.String(), ">HonestVoter, branch 1</text>")
+	assert.Contains(t, svg.String(), ">MinorityVoter, branch 2</text>")
+	assert.Contains(t, svg.String(), ">breaker threshold</text>")
+	assert.Contains(t, svg.String(), "stroke-dasharray", "the breaker threshold should be dashed")
+
+	var buf bytes.Buffer
+	require.NoError(t, timeSeries.WriteWeightChart(&buf, ChartFormatPNG))
+	image, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 960, image.Bounds().Dx())
+	assert.Equal(t, 540, image.Bounds().Dy())
+
+	assert.ErrorIs(t, timeSeries.WriteWeightChart(&buf, "gif"), ErrInvalidParameter)
+	assert.ErrorIs(t, TimeSeries{}.WriteWeightChart(&buf, ChartFormatSVG), ErrInvalidParameter)
+}
+
+func TestTimeSeries_WriteOpinionHeatmap(t *testing.T) {
+	timeSeries := chartTimeSeries()
+
+	var svg bytes.Buffer
+	require.NoError(t, timeSeries.WriteOpinionHeatmap(&svg, ChartFormatSVG))
+	assertWellFormedXML(t, svg.Bytes())
+	assert.Contains(t, svg.String(), ">HonestVoter 1</text>")
+	assert.Contains(t, svg.String(), ">MinorityVoter 2</text>")
+	assert.Contains(t, svg.String(), ">no vote</text>")
+
+	var buf bytes.Buffer
+	require.NoError(t, timeSeries.WriteOpinionHeatmap(&buf, ChartFormatPNG))
+	image, err := png.Decode(&buf)
+	require.NoError(t, err)
+
+	// the first HonestVoter flips from the first (blue) to the second (orange) Branch in the second half of the chart
+	firstRow := 40 + 8
+	assert.Equal(t, chartPalette[0], image.At(300, firstRow))
+	assert.Equal(t, chartPalette[1], image.At(700, firstRow))
+
+	// the MinorityVoter did not vote in the first sample
+	assert.Equal(t, chartBackground, image.At(180, firstRow+16))
+}
+
+// chartTimeSeries returns a TimeSeries in which an HonestVoter flips its opinion after a MinorityVoter voted.
+func chartTimeSeries() TimeSeries {
+	timeSeries := make(TimeSeries, 0)
+	for i := 0; i <= 10; i++ {
+		sample := &TimeSeriesSample{
+			Time: time.Duration(i) * 100 * time.Millisecond,
+			BranchWeights: map[string]map[BranchID]float64{
+				"HonestVoter": {NewBranchID(1): 0.8 - 0.05*float64(i), NewBranchID(2): 0.05 * float64(i)},
+			},
+			WeightGap:        0.8 - 0.1*float64(i),
+			BreakerThreshold: 0.066 * float64(i),
+			Opinions: map[VoterID]VoterOpinion{
+				1: {VoterType: "HonestVoter", BranchID: NewBranchID(1)},
+			},
+		}
+
+		if i != 0 {
+			sample.BranchWeights["MinorityVoter"] = map[BranchID]float64{NewBranchID(2): 0.2}
+			sample.Opinions[2] = VoterOpinion{VoterType: "MinorityVoter", BranchID: NewBranchID(2)}
+		}
+		if i > 5 {
+			sample.Opinions[1] = VoterOpinion{VoterType: "HonestVoter", BranchID: NewBranchID(2)}
+		}
+
+		timeSeries = append(timeSeries, sample)
+	}
+
+	return timeSeries
+}
+
+// assertWellFormedXML asserts that the given document can be parsed as XML.
+func assertWellFormedXML(t *testing.T, document []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			assert.Equal(t, "EOF", err.Error())
+
+			return
+		}
+	}
+}
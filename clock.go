@@ -0,0 +1,154 @@
+package metastabilitybreaker
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// region Clock ////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Clock is the source of time of a Network. It allows to run the same simulation in real time or in simulated time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep blocks until the given duration passed.
+	Sleep(duration time.Duration)
+
+	// AfterFunc executes the given callback once the given duration passed.
+	AfterFunc(duration time.Duration, callback func())
+
+	// Idle is called after a voting round in which no Voter voted. Time passes anyway in real time, but a simulated
+	// Clock needs to advance by the given duration to not get stuck.
+	Idle(duration time.Duration)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region RealClock ////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RealClock is the Clock that follows the wall clock.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) Sleep(duration time.Duration) {
+	time.Sleep(duration)
+}
+
+func (RealClock) AfterFunc(duration time.Duration, callback func()) {
+	time.AfterFunc(duration, callback)
+}
+
+func (RealClock) Idle(time.Duration) {}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region SimulatedClock ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// SimulatedClock is a Clock that only advances when it is told to sleep. Callbacks that are due are executed
+// synchronously (in order of their due time) by the goroutine that advances the SimulatedClock, so a simulation runs as
+// fast as the CPU allows.
+type SimulatedClock struct {
+	now          time.Time
+	timers       timerQueue
+	nextSequence uint64
+	mutex        sync.Mutex
+}
+
+// NewSimulatedClock returns a new SimulatedClock that starts at the given time.
+func NewSimulatedClock(startTime time.Time) *SimulatedClock {
+	return &SimulatedClock{
+		now: startTime,
+	}
+}
+
+func (s *SimulatedClock) Now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.now
+}
+
+func (s *SimulatedClock) Sleep(duration time.Duration) {
+	s.mutex.Lock()
+	targetTime := s.now.Add(duration)
+	for len(s.timers) != 0 && !s.timers[0].dueTime.After(targetTime) {
+		dueTimer := heap.Pop(&s.timers).(*timer)
+		if dueTimer.dueTime.After(s.now) {
+			s.now = dueTimer.dueTime
+		}
+
+		s.mutex.Unlock()
+		dueTimer.callback()
+		s.mutex.Lock()
+	}
+	if targetTime.After(s.now) {
+		s.now = targetTime
+	}
+	s.mutex.Unlock()
+}
+
+func (s *SimulatedClock) AfterFunc(duration time.Duration, callback func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	heap.Push(&s.timers, &timer{
+		dueTime:  s.now.Add(duration),
+		sequence: s.nextSequence,
+		callback: callback,
+	})
+	s.nextSequence++
+}
+
+func (s *SimulatedClock) Idle(duration time.Duration) {
+	s.Sleep(duration)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region timerQueue ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// timer is a callback that is scheduled on a SimulatedClock.
+type timer struct {
+	dueTime  time.Time
+	sequence uint64
+	callback func()
+}
+
+// timerQueue is a min-heap of timers that orders timers with the same due time by the order they were scheduled in.
+type timerQueue []*timer
+
+func (t timerQueue) Len() int {
+	return len(t)
+}
+
+func (t timerQueue) Less(i, j int) bool {
+	if t[i].dueTime.Equal(t[j].dueTime) {
+		return t[i].sequence < t[j].sequence
+	}
+
+	return t[i].dueTime.Before(t[j].dueTime)
+}
+
+func (t timerQueue) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+func (t *timerQueue) Push(element interface{}) {
+	*t = append(*t, element.(*timer))
+}
+
+func (t *timerQueue) Pop() interface{} {
+	old := *t
+	element := old[len(old)-1]
+	old[len(old)-1] = nil
+	*t = old[:len(old)-1]
+
+	return element
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,29 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulatedClock(t *testing.T) {
+	clock := NewSimulatedClock(simulationStartTime)
+
+	var executedCallbacks []int
+	clock.AfterFunc(2*time.Second, func() { executedCallbacks = append(executedCallbacks, 2) })
+	clock.AfterFunc(1*time.Second, func() {
+		executedCallbacks = append(executedCallbacks, 1)
+		assert.Equal(t, simulationStartTime.Add(1*time.Second), clock.Now())
+
+		clock.AfterFunc(0, func() { executedCallbacks = append(executedCallbacks, 3) })
+	})
+
+	clock.Sleep(500 * time.Millisecond)
+	assert.Empty(t, executedCallbacks)
+	assert.Equal(t, simulationStartTime.Add(500*time.Millisecond), clock.Now())
+
+	clock.Sleep(2 * time.Second)
+	assert.Equal(t, []int{1, 3, 2}, executedCallbacks)
+	assert.Equal(t, simulationStartTime.Add(2500*time.Millisecond), clock.Now())
+}
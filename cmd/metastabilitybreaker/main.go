@@ -0,0 +1,218 @@
+// Command metastabilitybreaker loads a scenario file and simulates it once, as a seeded Monte Carlo batch or as a
+// parameter sweep, replays a recorded vote trace with the parameters of the scenario or minimizes a failing vote trace.
+//
+// Usage:
+//
+//	metastabilitybreaker -scenario scenarios/minority-voter.yaml -mode batch -runs 1000 -format csv
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"metastabilitybreaker"
+)
+
+// errScenarioFailed is returned when the simulation does not meet the success criteria of the scenario.
+var errScenarioFailed = errors.New("scenario did not meet its success criteria")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		if errors.Is(err, errScenarioFailed) {
+			os.Exit(1)
+		}
+		os.Exit(2)
+	}
+}
+
+// run parses the given command line arguments, executes the simulation and writes the results.
+func run(args []string, stdout io.Writer) (err error) {
+	config, err := parseConfig(args)
+	if err != nil {
+		return err
+	}
+
+	scenario, err := metastabilitybreaker.LoadScenario(config.scenarioPath)
+	if err != nil {
+		return err
+	}
+	if config.duration != 0 {
+		scenario.Timeout = metastabilitybreaker.Duration(config.duration)
+	}
+
+	var report report
+	switch config.mode {
+	case "single":
+		report, err = runSingle(scenario, config, stdout)
+	case "batch":
+		report, err = runBatch(scenario, config)
+	case "sweep":
+		report, err = runSweep(scenario, config)
+	case "replay":
+		report, err = runReplay(scenario, config)
+	case "minimize":
+		report, err = runMinimize(scenario, config)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = writeReport(report, config, stdout); err != nil {
+		return err
+	}
+
+	if !report.Successful() {
+		return fmt.Errorf("%s: %w", scenario.Name, errScenarioFailed)
+	}
+
+	return nil
+}
+
+// region config ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// config contains the parsed command line flags.
+type config struct {
+	scenarioPath     string
+	mode             string
+	seed             int64
+	duration         time.Duration
+	runs             int
+	realTime         bool
+	printInterval    time.Duration
+	format           string
+	output           string
+	tracePath        string
+	rule             string
+	property         string
+	minimizedTrace   string
+	timeSeries       string
+	timeSeriesStep   time.Duration
+	metricsAddress   string
+	dashboardAddress string
+	charts           string
+	chartFormat      string
+	thresholds       durationList
+	latencies        durationList
+	attackerShares   floatList
+}
+
+// parseConfig parses the given command line arguments.
+func parseConfig(args []string) (c *config, err error) {
+	c = &config{}
+
+	flagSet := flag.NewFlagSet("metastabilitybreaker", flag.ContinueOnError)
+	flagSet.StringVar(&c.scenarioPath, "scenario", "", "path of the scenario file (YAML or JSON)")
+	flagSet.StringVar(&c.mode, "mode", "single", "simulation mode: single, batch, sweep, replay or minimize")
+	flagSet.Int64Var(&c.seed, "seed", time.Now().UnixNano(), "seed of the (first) run")
+	flagSet.DurationVar(&c.duration, "duration", 0, "maximum (simulated) duration of a run (overrides the timeout of the scenario)")
+	flagSet.IntVar(&c.runs, "runs", 100, "number of runs of a batch or per combination of parameters of a sweep")
+	flagSet.BoolVar(&c.realTime, "realtime", false, "run a single simulation in real time instead of simulated time")
+	flagSet.DurationVar(&c.printInterval, "print-interval", time.Second, "interval in which the state of a single run is printed (0 disables it)")
+	flagSet.StringVar(&c.format, "format", "text", "output format: text, json or csv")
+	flagSet.StringVar(&c.output, "output", "", "path of the results file (defaults to results/<scenario>-<mode>.<format>)")
+	flagSet.StringVar(&c.tracePath, "trace", "", "path of the JSON-lines vote trace that a single run records or that is replayed or minimized")
+	flagSet.StringVar(&c.rule, "rule", "breaker", "consensus rule of a replay or minimization: breaker or heaviest")
+	flagSet.StringVar(&c.property, "property", "metastable", "failure that a minimization preserves: metastable or safety")
+	flagSet.StringVar(&c.minimizedTrace, "minimized-trace", "", "path of the minimized vote trace (defaults to <trace>-minimized.jsonl)")
+	flagSet.StringVar(&c.timeSeries, "timeseries", "", "path of the CSV file that the time series of a single run is written to")
+	flagSet.DurationVar(&c.timeSeriesStep, "timeseries-interval", 100*time.Millisecond, "interval in which the time series of a single run is sampled")
+	flagSet.StringVar(&c.charts, "charts", "", "directory that the weight chart and the opinion heatmap of a single run are written to")
+	flagSet.StringVar(&c.chartFormat, "chart-format", "svg", "image format of the charts: svg or png")
+	flagSet.StringVar(&c.metricsAddress, "metrics-address", "", "address on which a single run exposes Prometheus metrics on /metrics (e.g. localhost:9090)")
+	flagSet.StringVar(&c.dashboardAddress, "dashboard-address", "", "address on which a single run serves a live dashboard (e.g. localhost:8080, best combined with -realtime)")
+	flagSet.Var(&c.thresholds, "thresholds", "comma separated MetastabilityBreakingThresholds of a sweep (defaults to the one of the scenario)")
+	flagSet.Var(&c.latencies, "latencies", "comma separated latencies of a sweep (defaults to the one of the scenario)")
+	flagSet.Var(&c.attackerShares, "attacker-shares", "comma separated shares of the attacker weight of a sweep (defaults to the one of the scenario)")
+	if err = flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if c.scenarioPath == "" && flagSet.NArg() == 1 {
+		c.scenarioPath = flagSet.Arg(0)
+	}
+	if c.scenarioPath == "" {
+		return nil, errors.New("missing scenario file (use -scenario)")
+	}
+	if c.mode != "single" && c.mode != "batch" && c.mode != "sweep" && c.mode != "replay" && c.mode != "minimize" {
+		return nil, fmt.Errorf("unknown mode %q (use single, batch, sweep, replay or minimize)", c.mode)
+	}
+	if (c.mode == "replay" || c.mode == "minimize") && c.tracePath == "" {
+		return nil, fmt.Errorf("missing trace file of the %s (use -trace)", c.mode)
+	}
+	if c.property != "metastable" && c.property != "safety" {
+		return nil, fmt.Errorf("unknown property %q (use metastable or safety)", c.property)
+	}
+	if c.format != "text" && c.format != "json" && c.format != "csv" {
+		return nil, fmt.Errorf("unknown format %q (use text, json or csv)", c.format)
+	}
+	if c.chartFormat != string(metastabilitybreaker.ChartFormatSVG) && c.chartFormat != string(metastabilitybreaker.ChartFormatPNG) {
+		return nil, fmt.Errorf("unknown chart format %q (use svg or png)", c.chartFormat)
+	}
+	if c.timeSeriesStep <= 0 {
+		return nil, fmt.Errorf("interval of the time series must be positive but is %s", c.timeSeriesStep)
+	}
+	if c.runs <= 0 {
+		return nil, fmt.Errorf("number of runs must be positive but is %d", c.runs)
+	}
+
+	return c, nil
+}
+
+// durationList is a flag.Value for comma separated durations.
+type durationList []time.Duration
+
+func (d *durationList) String() string {
+	values := make([]string, len(*d))
+	for i, value := range *d {
+		values[i] = value.String()
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (d *durationList) Set(value string) error {
+	for _, element := range strings.Split(value, ",") {
+		duration, err := time.ParseDuration(strings.TrimSpace(element))
+		if err != nil {
+			return err
+		}
+
+		*d = append(*d, duration)
+	}
+
+	return nil
+}
+
+// floatList is a flag.Value for comma separated floats.
+type floatList []float64
+
+func (f *floatList) String() string {
+	values := make([]string, len(*f))
+	for i, value := range *f {
+		values[i] = fmt.Sprintf("%g", value)
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (f *floatList) Set(value string) error {
+	for _, element := range strings.Split(value, ",") {
+		var float float64
+		if _, err := fmt.Sscanf(strings.TrimSpace(element), "%g", &float); err != nil {
+			return fmt.Errorf("invalid number %q", element)
+		}
+
+		*f = append(*f, float)
+	}
+
+	return nil
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"metastabilitybreaker"
+)
+
+const testScenario = "../../scenarios/minority-voter.yaml"
+
+func TestRun_Single(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "single.json")
+	timeSeriesPath := filepath.Join(t.TempDir(), "timeseries.csv")
+
+	var stdout bytes.Buffer
+	require.NoError(t, run([]string{"-scenario", testScenario, "-seed", "1", "-format", "json", "-output", outputPath, "-timeseries", timeSeriesPath}, &stdout))
+	assert.Contains(t, stdout.String(), "t=1s")
+
+	timeSeries := readCSV(t, timeSeriesPath)
+	require.Greater(t, len(timeSeries), 2)
+	assert.Equal(t, "time", timeSeries[0][0])
+	assert.Equal(t, "0.1", timeSeries[2][0])
+
+	data, err := ioutil.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	result := &singleReport{}
+	require.NoError(t, json.Unmarshal(data, result))
+	assert.Equal(t, "minority-voter", result.Scenario)
+	assert.True(t, result.Resolved)
+	assert.True(t, result.Success)
+}
+
+func TestRun_Charts(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "single.txt")
+	chartsPath := filepath.Join(t.TempDir(), "charts")
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-seed", "1", "-print-interval", "0", "-output", outputPath, "-charts", chartsPath}, &bytes.Buffer{}))
+	for _, chart := range []string{"minority-voter-weights.svg", "minority-voter-opinions.svg"} {
+		data, err := ioutil.ReadFile(filepath.Join(chartsPath, chart))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "<svg")
+	}
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-seed", "1", "-print-interval", "0", "-output", outputPath, "-charts", chartsPath, "-chart-format", "png"}, &bytes.Buffer{}))
+	assert.FileExists(t, filepath.Join(chartsPath, "minority-voter-weights.png"))
+	assert.FileExists(t, filepath.Join(chartsPath, "minority-voter-opinions.png"))
+}
+
+func TestRun_Metrics(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "single.txt")
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-seed", "1", "-print-interval", "0", "-metrics-address", "127.0.0.1:0", "-output", outputPath}, &bytes.Buffer{}))
+
+	err := run([]string{"-scenario", testScenario, "-seed", "1", "-print-interval", "0", "-metrics-address", "127.0.0.1:-1", "-output", outputPath}, &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errScenarioFailed)
+}
+
+func TestRun_Dashboard(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "single.txt")
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-seed", "1", "-print-interval", "0", "-dashboard-address", "127.0.0.1:0", "-output", outputPath}, &bytes.Buffer{}))
+
+	err := run([]string{"-scenario", testScenario, "-seed", "1", "-print-interval", "0", "-dashboard-address", "127.0.0.1:-1", "-output", outputPath}, &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errScenarioFailed)
+}
+
+func TestRun_Batch(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "batch.csv")
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-mode", "batch", "-runs", "200", "-seed", "1", "-format", "csv", "-output", outputPath}, &bytes.Buffer{}))
+
+	records := readCSV(t, outputPath)
+	require.Len(t, records, 2)
+	assert.Equal(t, "runs", records[0][2])
+	assert.Equal(t, "200", records[1][2])
+
+	// too few runs to prove the required probability with 95% confidence
+	err := run([]string{"-scenario", testScenario, "-mode", "batch", "-runs", "10", "-output", outputPath}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, errScenarioFailed)
+}
+
+func TestRun_Sweep(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "sweep.csv")
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-mode", "sweep", "-runs", "5", "-seed", "1", "-thresholds", "0s,5s", "-attacker-shares", "0.1,0.3", "-format", "csv", "-output", outputPath}, &bytes.Buffer{}))
+
+	records := readCSV(t, outputPath)
+	require.Len(t, records, 5)
+	assert.Equal(t, []string{"0", "0.1", "8", "0.2"}, records[1][2:6])
+	assert.Equal(t, []string{"5", "0.3", "8", "0.2"}, records[4][2:6])
+}
+
+func TestRun_Replay(t *testing.T) {
+	tracePath := filepath.Join(t.TempDir(), "trace.jsonl")
+	outputPath := filepath.Join(t.TempDir(), "replay.json")
+
+	require.NoError(t, run([]string{"-scenario", testScenario, "-seed", "3", "-print-interval", "0", "-trace", tracePath, "-output", outputPath}, &bytes.Buffer{}))
+	require.NoError(t, run([]string{"-scenario", testScenario, "-mode", "replay", "-trace", tracePath, "-format", "json", "-output", outputPath}, &bytes.Buffer{}))
+
+	data, err := ioutil.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	result := &replayReport{}
+	require.NoError(t, json.Unmarshal(data, result))
+	assert.NotZero(t, result.Decisions)
+	assert.True(t, result.Reproduced)
+	assert.Empty(t, result.Divergences)
+}
+
+func TestRun_Minimize(t *testing.T) {
+	tracePath := filepath.Join(t.TempDir(), "trace.jsonl")
+	outputPath := filepath.Join(t.TempDir(), "minimize.json")
+
+	// the short run ends while the HonestVoters are still split
+	err := run([]string{"-scenario", testScenario, "-seed", "12", "-duration", "500ms", "-print-interval", "0", "-trace", tracePath, "-output", outputPath}, &bytes.Buffer{})
+	require.ErrorIs(t, err, errScenarioFailed)
+	require.NoError(t, run([]string{"-scenario", testScenario, "-mode", "minimize", "-trace", tracePath, "-format", "json", "-output", outputPath}, &bytes.Buffer{}))
+
+	data, err := ioutil.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	result := &minimizeReport{}
+	require.NoError(t, json.Unmarshal(data, result))
+	assert.Less(t, len(result.Votes), result.OriginalVotes)
+	assert.Contains(t, result.Narrative, "the HonestVoters are still split")
+	assert.FileExists(t, filepath.Join(filepath.Dir(tracePath), "trace-minimized.jsonl"))
+
+	err = run([]string{"-scenario", testScenario, "-mode", "minimize", "-trace", tracePath, "-property", "safety", "-output", outputPath}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, metastabilitybreaker.ErrInvalidParameter, "the trace does not violate safety")
+}
+
+func TestParseConfig(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"-scenario", testScenario, "-mode", "unknown"},
+		{"-scenario", testScenario, "-format", "xml"},
+		{"-scenario", testScenario, "-runs", "0"},
+		{"-scenario", testScenario, "-thresholds", "5"},
+		{"-scenario", testScenario, "-mode", "replay"},
+		{"-scenario", testScenario, "-mode", "minimize"},
+		{"-scenario", testScenario, "-property", "liveness"},
+		{"-scenario", testScenario, "-timeseries-interval", "0s"},
+		{"-scenario", testScenario, "-chart-format", "gif"},
+	} {
+		_, err := parseConfig(args)
+		assert.Error(t, err, args)
+	}
+
+	config, err := parseConfig([]string{"-mode", "batch", "-format", "csv", testScenario})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("results", "minority-voter-batch.csv"), defaultOutputPath(config))
+}
+
+func readCSV(t *testing.T, path string) (records [][]string) {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, err = csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+
+	return records
+}
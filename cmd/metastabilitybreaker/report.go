@@ -0,0 +1,451 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"metastabilitybreaker"
+)
+
+// region report ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// report is the result of a simulation that can be written as text, JSON or CSV. Durations are written in seconds.
+type report interface {
+	// Successful returns true if the simulation met the success criteria of the scenario.
+	Successful() bool
+
+	// Records returns the header and the rows of the CSV representation of the report.
+	Records() (header []string, rows [][]string)
+
+	String() string
+}
+
+// writeReport prints the report as text and writes it in the configured format to the configured output file.
+func writeReport(report report, config *config, stdout io.Writer) (err error) {
+	fmt.Fprintln(stdout, report)
+
+	outputPath := config.output
+	if outputPath == "" {
+		outputPath = defaultOutputPath(config)
+	}
+	if err = os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	switch config.format {
+	case "json":
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case "csv":
+		header, rows := report.Records()
+		writer := csv.NewWriter(file)
+		if err = writer.Write(header); err == nil {
+			err = writer.WriteAll(rows)
+		}
+	default:
+		_, err = fmt.Fprint(file, report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write output file %s: %w", outputPath, err)
+	}
+
+	fmt.Fprintf(stdout, "results written to %s\n", outputPath)
+
+	return file.Close()
+}
+
+// defaultOutputPath returns results/<scenario>-<mode>.<format> with the name of the scenario file.
+func defaultOutputPath(config *config) string {
+	name := strings.TrimSuffix(filepath.Base(config.scenarioPath), filepath.Ext(config.scenarioPath))
+	extension := config.format
+	if extension == "text" {
+		extension = "txt"
+	}
+
+	return filepath.Join("results", fmt.Sprintf("%s-%s.%s", name, config.mode, extension))
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region singleReport /////////////////////////////////////////////////////////////////////////////////////////////////
+
+// singleReport is the result of a single run.
+type singleReport struct {
+	Scenario        string  `json:"scenario"`
+	Seed            int64   `json:"seed"`
+	Resolved        bool    `json:"resolved"`
+	ResolutionTime  float64 `json:"resolutionTime"`
+	ConfirmedBranch string  `json:"confirmedBranch,omitempty"`
+	Success         bool    `json:"successful"`
+
+	network string
+}
+
+func (s *singleReport) Successful() bool {
+	return s.Success
+}
+
+func (s *singleReport) Records() (header []string, rows [][]string) {
+	return []string{"scenario", "seed", "resolved", "resolutionTime", "confirmedBranch", "successful"}, [][]string{{
+		s.Scenario,
+		fmt.Sprintf("%d", s.Seed),
+		fmt.Sprintf("%t", s.Resolved),
+		fmt.Sprintf("%g", s.ResolutionTime),
+		s.ConfirmedBranch,
+		fmt.Sprintf("%t", s.Success),
+	}}
+}
+
+func (s *singleReport) String() string {
+	return fmt.Sprintf("%s\nScenario: %s, Seed: %d, Resolved: %t, ResolutionTime: %s, ConfirmedBranch: %s, Successful: %t\n",
+		s.network, s.Scenario, s.Seed, s.Resolved, time.Duration(s.ResolutionTime*float64(time.Second)), s.ConfirmedBranch, s.Success)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region batchReport //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// batchReport is the result of a Batch.
+type batchReport struct {
+	Scenario              string  `json:"scenario"`
+	Seed                  int64   `json:"seed"`
+	Runs                  int     `json:"runs"`
+	ResolvedRuns          int     `json:"resolvedRuns"`
+	ConfidenceLevel       float64 `json:"confidenceLevel"`
+	ResolutionProbability float64 `json:"resolutionProbability"`
+	LowerBound            float64 `json:"lowerBound"`
+	UpperBound            float64 `json:"upperBound"`
+	MeanResolutionTime    float64 `json:"meanResolutionTime"`
+	P50ResolutionTime     float64 `json:"p50ResolutionTime"`
+	P90ResolutionTime     float64 `json:"p90ResolutionTime"`
+	P95ResolutionTime     float64 `json:"p95ResolutionTime"`
+	P99ResolutionTime     float64 `json:"p99ResolutionTime"`
+	MaxResolutionTime     float64 `json:"maxResolutionTime"`
+	MeanOpinionFlips      float64 `json:"meanOpinionFlips"`
+	Success               bool    `json:"successful"`
+
+	result *metastabilitybreaker.BatchResult
+}
+
+// newBatchReport returns the report of the given BatchResult.
+func newBatchReport(scenario *metastabilitybreaker.Scenario, seed int64, result *metastabilitybreaker.BatchResult) *batchReport {
+	probability, lowerBound, upperBound := result.ResolutionProbability()
+
+	return &batchReport{
+		Scenario:              scenario.Name,
+		Seed:                  seed,
+		Runs:                  result.Runs,
+		ResolvedRuns:          result.ResolvedRuns,
+		ConfidenceLevel:       result.ConfidenceLevel,
+		ResolutionProbability: probability,
+		LowerBound:            lowerBound,
+		UpperBound:            upperBound,
+		MeanResolutionTime:    result.MeanResolutionTime().Seconds(),
+		P50ResolutionTime:     result.Percentile(0.5).Seconds(),
+		P90ResolutionTime:     result.Percentile(0.9).Seconds(),
+		P95ResolutionTime:     result.Percentile(0.95).Seconds(),
+		P99ResolutionTime:     result.Percentile(0.99).Seconds(),
+		MaxResolutionTime:     result.Percentile(1).Seconds(),
+		MeanOpinionFlips:      result.MeanOpinionFlips,
+		Success:               scenario.Success.EvaluateBatch(result),
+		result:                result,
+	}
+}
+
+func (b *batchReport) Successful() bool {
+	return b.Success
+}
+
+func (b *batchReport) Records() (header []string, rows [][]string) {
+	header = []string{"scenario", "seed", "runs", "resolvedRuns", "confidenceLevel", "resolutionProbability", "lowerBound", "upperBound", "meanResolutionTime", "p50ResolutionTime", "p90ResolutionTime", "p95ResolutionTime", "p99ResolutionTime", "maxResolutionTime", "meanOpinionFlips", "successful"}
+
+	return header, [][]string{{
+		b.Scenario,
+		fmt.Sprintf("%d", b.Seed),
+		fmt.Sprintf("%d", b.Runs),
+		fmt.Sprintf("%d", b.ResolvedRuns),
+		fmt.Sprintf("%g", b.ConfidenceLevel),
+		fmt.Sprintf("%g", b.ResolutionProbability),
+		fmt.Sprintf("%g", b.LowerBound),
+		fmt.Sprintf("%g", b.UpperBound),
+		fmt.Sprintf("%g", b.MeanResolutionTime),
+		fmt.Sprintf("%g", b.P50ResolutionTime),
+		fmt.Sprintf("%g", b.P90ResolutionTime),
+		fmt.Sprintf("%g", b.P95ResolutionTime),
+		fmt.Sprintf("%g", b.P99ResolutionTime),
+		fmt.Sprintf("%g", b.MaxResolutionTime),
+		fmt.Sprintf("%g", b.MeanOpinionFlips),
+		fmt.Sprintf("%t", b.Success),
+	}}
+}
+
+func (b *batchReport) String() string {
+	return fmt.Sprintf("Scenario: %s, Seed: %d, Successful: %t\n%s", b.Scenario, b.Seed, b.Success, b.result)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region sweepReport //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sweepReport is the result of a Sweep. A Sweep explores the parameter space, so it is always considered successful.
+type sweepReport struct {
+	Scenario string            `json:"scenario"`
+	Seed     int64             `json:"seed"`
+	Results  []*sweepReportRow `json:"results"`
+
+	results metastabilitybreaker.SweepResults
+}
+
+// sweepReportRow is the result of a single combination of parameters of a Sweep.
+type sweepReportRow struct {
+	MetastabilityBreakingThreshold float64 `json:"metastabilityBreakingThreshold"`
+	AttackerShare                  float64 `json:"attackerShare"`
+	HonestVoters                   int     `json:"honestVoters"`
+	Latency                        float64 `json:"latency"`
+	Runs                           int     `json:"runs"`
+	ResolutionProbability          float64 `json:"resolutionProbability"`
+	MedianResolutionTime           float64 `json:"medianResolutionTime"`
+	P95ResolutionTime              float64 `json:"p95ResolutionTime"`
+	MeanOpinionFlips               float64 `json:"meanOpinionFlips"`
+}
+
+// newSweepReport returns the report of the given SweepResults.
+func newSweepReport(scenario *metastabilitybreaker.Scenario, seed int64, results metastabilitybreaker.SweepResults) (report *sweepReport) {
+	report = &sweepReport{
+		Scenario: scenario.Name,
+		Seed:     seed,
+		results:  results,
+	}
+
+	for _, result := range results {
+		report.Results = append(report.Results, &sweepReportRow{
+			MetastabilityBreakingThreshold: result.MetastabilityBreakingThreshold.Seconds(),
+			AttackerShare:                  result.AttackerWeight,
+			HonestVoters:                   result.HonestVoters,
+			Latency:                        result.Latency.Seconds(),
+			Runs:                           result.Runs,
+			ResolutionProbability:          result.ResolutionProbability,
+			MedianResolutionTime:           result.MedianResolutionTime.Seconds(),
+			P95ResolutionTime:              result.P95ResolutionTime.Seconds(),
+			MeanOpinionFlips:               result.MeanOpinionFlips,
+		})
+	}
+
+	return report
+}
+
+func (s *sweepReport) Successful() bool {
+	return true
+}
+
+func (s *sweepReport) Records() (header []string, rows [][]string) {
+	header = []string{"scenario", "seed", "metastabilityBreakingThreshold", "attackerShare", "honestVoters", "latency", "runs", "resolutionProbability", "medianResolutionTime", "p95ResolutionTime", "meanOpinionFlips"}
+
+	for _, row := range s.Results {
+		rows = append(rows, []string{
+			s.Scenario,
+			fmt.Sprintf("%d", s.Seed),
+			fmt.Sprintf("%g", row.MetastabilityBreakingThreshold),
+			fmt.Sprintf("%g", row.AttackerShare),
+			fmt.Sprintf("%d", row.HonestVoters),
+			fmt.Sprintf("%g", row.Latency),
+			fmt.Sprintf("%d", row.Runs),
+			fmt.Sprintf("%g", row.ResolutionProbability),
+			fmt.Sprintf("%g", row.MedianResolutionTime),
+			fmt.Sprintf("%g", row.P95ResolutionTime),
+			fmt.Sprintf("%g", row.MeanOpinionFlips),
+		})
+	}
+
+	return header, rows
+}
+
+func (s *sweepReport) String() string {
+	return fmt.Sprintf("Scenario: %s, Seed: %d\n%s", s.Scenario, s.Seed, s.results)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region replayReport /////////////////////////////////////////////////////////////////////////////////////////////////
+
+// replayReport is the result of a Replay. Diverging decisions are the expected outcome of replaying a trace with a
+// different rule, so a replay is always considered successful.
+type replayReport struct {
+	Scenario    string                `json:"scenario"`
+	Trace       string                `json:"trace"`
+	Rule        string                `json:"rule"`
+	Decisions   int                   `json:"decisions"`
+	Reproduced  bool                  `json:"reproduced"`
+	Divergences []*replayReportRecord `json:"divergences"`
+
+	result *metastabilitybreaker.ReplayResult
+}
+
+// replayReportRecord is a decision of a Replay that differs from the recorded one.
+type replayReportRecord struct {
+	Time           float64 `json:"time"`
+	Voter          int     `json:"voter"`
+	VoterType      string  `json:"voterType"`
+	Issuer         int     `json:"issuer"`
+	BranchID       int     `json:"branchID"`
+	RecordedBranch int     `json:"recordedBranch"`
+	ReplayedBranch int     `json:"replayedBranch"`
+}
+
+// newReplayReport returns the report of the given ReplayResult.
+func newReplayReport(scenario *metastabilitybreaker.Scenario, config *config, result *metastabilitybreaker.ReplayResult) (report *replayReport) {
+	report = &replayReport{
+		Scenario:    scenario.Name,
+		Trace:       config.tracePath,
+		Rule:        config.rule,
+		Decisions:   len(result.Decisions),
+		Reproduced:  result.Reproduced(),
+		Divergences: make([]*replayReportRecord, 0),
+		result:      result,
+	}
+
+	for _, divergence := range result.Divergences() {
+		report.Divergences = append(report.Divergences, &replayReportRecord{
+			Time:           divergence.Time.Seconds(),
+			Voter:          int(divergence.Voter),
+			VoterType:      divergence.VoterType,
+			Issuer:         int(divergence.Vote.Issuer),
+			BranchID:       int(divergence.Vote.BranchID),
+			RecordedBranch: int(divergence.RecordedBranch),
+			ReplayedBranch: int(divergence.ReplayedBranch),
+		})
+	}
+
+	return report
+}
+
+func (r *replayReport) Successful() bool {
+	return true
+}
+
+func (r *replayReport) Records() (header []string, rows [][]string) {
+	header = []string{"scenario", "rule", "time", "voter", "voterType", "issuer", "branchID", "recordedBranch", "replayedBranch"}
+
+	for _, divergence := range r.Divergences {
+		rows = append(rows, []string{
+			r.Scenario,
+			r.Rule,
+			fmt.Sprintf("%g", divergence.Time),
+			fmt.Sprintf("%d", divergence.Voter),
+			divergence.VoterType,
+			fmt.Sprintf("%d", divergence.Issuer),
+			fmt.Sprintf("%d", divergence.BranchID),
+			fmt.Sprintf("%d", divergence.RecordedBranch),
+			fmt.Sprintf("%d", divergence.ReplayedBranch),
+		})
+	}
+
+	return header, rows
+}
+
+func (r *replayReport) String() string {
+	return fmt.Sprintf("Scenario: %s, Trace: %s, Rule: %s, Reproduced: %t\n%s", r.Scenario, r.Trace, r.Rule, r.Reproduced, r.result)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region minimizeReport ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// minimizeReport is the result of a TraceMinimization. The minimization fails with an error if the trace does not show
+// the failure, so a minimization that returns a report is always considered successful.
+type minimizeReport struct {
+	Scenario       string                  `json:"scenario"`
+	Trace          string                  `json:"trace"`
+	MinimizedTrace string                  `json:"minimizedTrace"`
+	Rule           string                  `json:"rule"`
+	Property       string                  `json:"property"`
+	OriginalVotes  int                     `json:"originalVotes"`
+	Replays        int                     `json:"replays"`
+	Votes          []*minimizeReportRecord `json:"votes"`
+	Narrative      string                  `json:"narrative"`
+}
+
+// minimizeReportRecord is a vote of the minimal trace.
+type minimizeReportRecord struct {
+	Time         float64 `json:"time"`
+	Issuer       int     `json:"issuer"`
+	IssuerType   string  `json:"issuerType"`
+	IssuerWeight float64 `json:"issuerWeight"`
+	BranchID     int     `json:"branchID"`
+	Processed    int     `json:"processed"`
+}
+
+// newMinimizeReport returns the report of the given TraceMinimizationResult.
+func newMinimizeReport(scenario *metastabilitybreaker.Scenario, config *config, minimizedTracePath string, result *metastabilitybreaker.TraceMinimizationResult) (report *minimizeReport) {
+	report = &minimizeReport{
+		Scenario:       scenario.Name,
+		Trace:          config.tracePath,
+		MinimizedTrace: minimizedTracePath,
+		Rule:           config.rule,
+		Property:       config.property,
+		OriginalVotes:  result.OriginalVotes,
+		Replays:        result.Replays,
+		Votes:          make([]*minimizeReportRecord, 0, len(result.Votes)),
+		Narrative:      result.Narrative(),
+	}
+
+	for _, vote := range result.Votes {
+		record := vote.Sent
+		if record == nil {
+			record = vote.Processed[0]
+		}
+
+		report.Votes = append(report.Votes, &minimizeReportRecord{
+			Time:         record.Time.Sub(result.StartTime).Seconds(),
+			Issuer:       int(record.Issuer),
+			IssuerType:   record.IssuerType,
+			IssuerWeight: record.IssuerWeight,
+			BranchID:     int(record.BranchID),
+			Processed:    len(vote.Processed),
+		})
+	}
+
+	return report
+}
+
+func (m *minimizeReport) Successful() bool {
+	return true
+}
+
+func (m *minimizeReport) Records() (header []string, rows [][]string) {
+	header = []string{"scenario", "property", "time", "issuer", "issuerType", "issuerWeight", "branchID", "processed"}
+
+	for _, vote := range m.Votes {
+		rows = append(rows, []string{
+			m.Scenario,
+			m.Property,
+			fmt.Sprintf("%g", vote.Time),
+			fmt.Sprintf("%d", vote.Issuer),
+			vote.IssuerType,
+			fmt.Sprintf("%g", vote.IssuerWeight),
+			fmt.Sprintf("%d", vote.BranchID),
+			fmt.Sprintf("%d", vote.Processed),
+		})
+	}
+
+	return header, rows
+}
+
+func (m *minimizeReport) String() string {
+	return fmt.Sprintf("Scenario: %s, Trace: %s, Rule: %s, Property: %s, Minimized Trace: %s\n%s", m.Scenario, m.Trace, m.Rule, m.Property, m.MinimizedTrace, m.Narrative)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"metastabilitybreaker"
+)
+
+// simulationStartTime is the time at which the SimulatedClock of a single run starts.
+var simulationStartTime = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// dashboardInterval is the interval in which the live dashboard of a single run pushes the state of the Network.
+const dashboardInterval = 250 * time.Millisecond
+
+// region single ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runSingle simulates the scenario once and prints the state of the Network in the configured interval.
+func runSingle(scenario *metastabilitybreaker.Scenario, config *config, stdout io.Writer) (result *singleReport, err error) {
+	setup, err := scenario.Setup()
+	if err != nil {
+		return nil, err
+	}
+
+	// the Network is set up in the same order as the runs of a Batch, so run i of a Batch can be reproduced with the
+	// seed of the Batch + i
+	network := metastabilitybreaker.NewNetwork(0)
+	if !config.realTime {
+		network.Clock = metastabilitybreaker.NewSimulatedClock(simulationStartTime)
+	}
+	network.Random = metastabilitybreaker.NewRandom(config.seed)
+	setup(network)
+	defer network.Shutdown()
+
+	if config.tracePath != "" {
+		traceFile, traceErr := os.Create(config.tracePath)
+		if traceErr != nil {
+			return nil, fmt.Errorf("failed to create trace file: %w", traceErr)
+		}
+		defer traceFile.Close()
+
+		tracer := metastabilitybreaker.NewTracer(network, traceFile)
+		defer func() {
+			tracer.Detach()
+			if err == nil && tracer.Err() != nil {
+				err = fmt.Errorf("failed to write trace file: %w", tracer.Err())
+			}
+		}()
+	}
+
+	if config.metricsAddress != "" {
+		stopMetrics, metricsErr := serveMetrics(config.metricsAddress, network)
+		if metricsErr != nil {
+			return nil, metricsErr
+		}
+		defer stopMetrics()
+	}
+
+	if config.dashboardAddress != "" {
+		stopDashboard, dashboardErr := serveDashboard(config.dashboardAddress, network)
+		if dashboardErr != nil {
+			return nil, dashboardErr
+		}
+		defer stopDashboard()
+	}
+
+	var timeSeriesRecorder *metastabilitybreaker.TimeSeriesRecorder
+	if config.timeSeries != "" || config.charts != "" {
+		timeSeriesRecorder = metastabilitybreaker.NewTimeSeriesRecorder(network, config.timeSeriesStep)
+		if err = timeSeriesRecorder.Start(); err != nil {
+			return nil, err
+		}
+	}
+
+	printer := newStatePrinter(network, config.printInterval, stdout)
+	printer.Start()
+	resolved, resolutionTime, err := network.Simulate(time.Duration(scenario.Timeout), scenario.BranchIDs()...)
+	printer.Stop()
+	if err != nil {
+		return nil, err
+	}
+
+	if timeSeriesRecorder != nil {
+		timeSeriesRecorder.Stop()
+		if config.timeSeries != "" {
+			if err = writeTimeSeries(config.timeSeries, timeSeriesRecorder.TimeSeries()); err != nil {
+				return nil, err
+			}
+		}
+		if config.charts != "" {
+			if err = writeCharts(config, timeSeriesRecorder.TimeSeries()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result = &singleReport{
+		Scenario:       scenario.Name,
+		Seed:           config.seed,
+		Resolved:       resolved,
+		ResolutionTime: resolutionTime.Seconds(),
+		Success:        scenario.Success.Evaluate(resolved, resolutionTime),
+		network:        network.String(),
+	}
+	if confirmedBranch, confirmed := network.ConfirmedBranch(); confirmed {
+		result.ConfirmedBranch = confirmedBranch.String()
+	}
+
+	return result, nil
+}
+
+// serveMetrics exposes the metrics of the given Network on the /metrics path of the given address until the returned
+// function is called.
+func serveMetrics(address string, network *metastabilitybreaker.Network) (stop func(), err error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics requests: %w", err)
+	}
+
+	metricsExporter := metastabilitybreaker.NewMetricsExporter(network)
+	serveMux := http.NewServeMux()
+	serveMux.Handle("/metrics", metricsExporter)
+	server := &http.Server{Handler: serveMux}
+	go server.Serve(listener)
+
+	return func() {
+		server.Close()
+		metricsExporter.Detach()
+	}, nil
+}
+
+// serveDashboard serves the live Dashboard of the given Network on the given address until the returned function is
+// called.
+func serveDashboard(address string, network *metastabilitybreaker.Network) (stop func(), err error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for dashboard requests: %w", err)
+	}
+
+	dashboard := metastabilitybreaker.NewDashboard(network, dashboardInterval)
+	server := &http.Server{Handler: dashboard}
+	go server.Serve(listener)
+
+	return func() {
+		dashboard.Close()
+		server.Close()
+	}, nil
+}
+
+// writeTimeSeries writes the given TimeSeries as CSV to the file with the given path.
+func writeTimeSeries(path string, timeSeries metastabilitybreaker.TimeSeries) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create time series file: %w", err)
+	}
+	defer file.Close()
+
+	return timeSeries.WriteCSV(file)
+}
+
+// writeCharts writes the weight chart and the opinion heatmap of the given TimeSeries to the chart directory (named
+// after the scenario file).
+func writeCharts(config *config, timeSeries metastabilitybreaker.TimeSeries) (err error) {
+	if err = os.MkdirAll(config.charts, 0o755); err != nil {
+		return fmt.Errorf("failed to create chart directory: %w", err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(config.scenarioPath), filepath.Ext(config.scenarioPath))
+	format := metastabilitybreaker.ChartFormat(config.chartFormat)
+	if err = writeChart(filepath.Join(config.charts, fmt.Sprintf("%s-weights.%s", name, format)), format, timeSeries.WriteWeightChart); err != nil {
+		return err
+	}
+
+	return writeChart(filepath.Join(config.charts, fmt.Sprintf("%s-opinions.%s", name, format)), format, timeSeries.WriteOpinionHeatmap)
+}
+
+// writeChart writes a single chart to the file with the given path.
+func writeChart(path string, format metastabilitybreaker.ChartFormat, write func(io.Writer, metastabilitybreaker.ChartFormat) error) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create chart file: %w", err)
+	}
+	defer file.Close()
+
+	return write(file, format)
+}
+
+// statePrinter prints the state of a Network in a fixed interval of the Clock of the Network.
+type statePrinter struct {
+	network  *metastabilitybreaker.Network
+	interval time.Duration
+	writer   io.Writer
+	stopped  bool
+	mutex    sync.Mutex
+}
+
+// newStatePrinter returns a statePrinter that writes the state of the given Network to the given writer.
+func newStatePrinter(network *metastabilitybreaker.Network, interval time.Duration, writer io.Writer) *statePrinter {
+	return &statePrinter{
+		network:  network,
+		interval: interval,
+		writer:   writer,
+	}
+}
+
+// Start schedules the first print (an interval of 0 disables the printing).
+func (s *statePrinter) Start() {
+	if s.interval <= 0 {
+		return
+	}
+
+	startTime := s.network.Clock.Now()
+
+	var print func()
+	print = func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if s.stopped {
+			return
+		}
+
+		fmt.Fprintf(s.writer, "t=%s\n%s\n", s.network.Clock.Now().Sub(startTime), s.network)
+		s.network.Clock.AfterFunc(s.interval, print)
+	}
+
+	s.network.Clock.AfterFunc(s.interval, print)
+}
+
+// Stop prevents any further prints.
+func (s *statePrinter) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stopped = true
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region batch ////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runBatch simulates the scenario as a seeded Monte Carlo batch.
+func runBatch(scenario *metastabilitybreaker.Scenario, config *config) (result *batchReport, err error) {
+	setup, err := scenario.Setup()
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &metastabilitybreaker.Batch{
+		Scenario:  setup,
+		Runs:      config.runs,
+		Seed:      config.seed,
+		BranchIDs: scenario.BranchIDs(),
+		Timeout:   time.Duration(scenario.Timeout),
+	}
+
+	batchResult, err := batch.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return newBatchReport(scenario, config.seed, batchResult), nil
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region replay ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runReplay replays the configured trace against a Network with the parameters of the scenario and the configured
+// ConsensusRule.
+func runReplay(scenario *metastabilitybreaker.Scenario, config *config) (result *replayReport, err error) {
+	setup, err := scenario.Setup()
+	if err != nil {
+		return nil, err
+	}
+
+	consensusRule, err := newConsensusRule(config.rule)
+	if err != nil {
+		return nil, err
+	}
+
+	traceFile, err := os.Open(config.tracePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer traceFile.Close()
+
+	trace, err := metastabilitybreaker.ReadTrace(traceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	replayResult, err := (&metastabilitybreaker.Replay{
+		Trace: trace,
+		Setup: func(network *metastabilitybreaker.Network) {
+			setup(network)
+			network.ConsensusRule = consensusRule
+		},
+	}).Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return newReplayReport(scenario, config, replayResult), nil
+}
+
+// runMinimize minimizes the vote trace of a failing run and writes the minimal trace.
+func runMinimize(scenario *metastabilitybreaker.Scenario, config *config) (result *minimizeReport, err error) {
+	setup, err := scenario.Setup()
+	if err != nil {
+		return nil, err
+	}
+
+	consensusRule, err := newConsensusRule(config.rule)
+	if err != nil {
+		return nil, err
+	}
+
+	traceFile, err := os.Open(config.tracePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer traceFile.Close()
+
+	trace, err := metastabilitybreaker.ReadTrace(traceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	failure := metastabilitybreaker.TraceFailure((*metastabilitybreaker.ReplayResult).Metastable)
+	if config.property == "safety" {
+		failure = (*metastabilitybreaker.ReplayResult).SafetyViolated
+	}
+
+	minimizationResult, err := (&metastabilitybreaker.TraceMinimization{
+		Trace: trace,
+		Setup: func(network *metastabilitybreaker.Network) {
+			setup(network)
+			network.ConsensusRule = consensusRule
+		},
+		Failure: failure,
+	}).Run()
+	if err != nil {
+		return nil, err
+	}
+
+	minimizedTracePath := config.minimizedTrace
+	if minimizedTracePath == "" {
+		minimizedTracePath = strings.TrimSuffix(config.tracePath, filepath.Ext(config.tracePath)) + "-minimized.jsonl"
+	}
+
+	minimizedTraceFile, err := os.Create(minimizedTracePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minimized trace file: %w", err)
+	}
+	defer minimizedTraceFile.Close()
+
+	if err = metastabilitybreaker.WriteTrace(minimizedTraceFile, minimizationResult.Trace); err != nil {
+		return nil, err
+	}
+
+	return newMinimizeReport(scenario, config, minimizedTracePath, minimizationResult), nil
+}
+
+// newConsensusRule returns the ConsensusRule with the given name.
+func newConsensusRule(name string) (consensusRule metastabilitybreaker.ConsensusRule, err error) {
+	switch name {
+	case "breaker":
+		return metastabilitybreaker.MetastabilityBreakerRule(), nil
+	case "heaviest":
+		return metastabilitybreaker.HeaviestBranchRule(), nil
+	default:
+		return nil, fmt.Errorf("unknown consensus rule %q (use breaker or heaviest)", name)
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region sweep ////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runSweep simulates the scenario across the configured thresholds, latencies and attacker shares. Dimensions that are
+// not configured use the value of the scenario.
+func runSweep(scenario *metastabilitybreaker.Scenario, config *config) (result *sweepReport, err error) {
+	setup, err := scenario.Setup()
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := scenario.NewNetwork()
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds := config.thresholds
+	if len(thresholds) == 0 {
+		thresholds = durationList{time.Duration(scenario.MetastabilityBreakingThreshold)}
+	}
+	latencies := config.latencies
+	if len(latencies) == 0 {
+		latencies = durationList{time.Duration(scenario.Latency)}
+	}
+	stats := network.WeightDistributionStats()
+	attackerShares := config.attackerShares
+	rescaleWeights := len(attackerShares) != 0
+	if !rescaleWeights {
+		attackerShares = floatList{stats.AttackerShare}
+	}
+
+	sweep := &metastabilitybreaker.Sweep{
+		Scenario: func(network *metastabilitybreaker.Network, parameters metastabilitybreaker.SweepParameters) {
+			setup(network)
+			network.MetastabilityBreakingThreshold = parameters.MetastabilityBreakingThreshold
+			network.PerceptionNoise.MaxUpdateDelay = parameters.Latency
+			if rescaleWeights {
+				setAttackerShare(network, parameters.AttackerWeight)
+			}
+		},
+		MetastabilityBreakingThresholds: thresholds,
+		ConfirmationThresholds:          []float64{network.ConfirmationThreshold},
+		BreakerCeilings:                 []float64{network.BreakerCeiling},
+		AttackerWeights:                 attackerShares,
+		HonestVoters:                    []int{stats.HonestVoters},
+		Latencies:                       latencies,
+		WeightDeviations:                []float64{network.PerceptionNoise.MaxWeightDeviation},
+		Runs:                            config.runs,
+		Seed:                            config.seed,
+		BranchIDs:                       scenario.BranchIDs(),
+		Timeout:                         time.Duration(scenario.Timeout),
+	}
+
+	sweepResults, err := sweep.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return newSweepReport(scenario, config.seed, sweepResults), nil
+}
+
+// setAttackerShare rescales the weights of the Voters so that the attackers own the given share of the total weight
+// while the relative proportions within the honest Voters and the attackers are kept.
+func setAttackerShare(network *metastabilitybreaker.Network, share float64) {
+	stats := network.WeightDistributionStats()
+	if stats.AttackerShare == 0 || stats.AttackerShare == 1 {
+		return
+	}
+
+	for _, voter := range network.Voters() {
+		weight := network.WeightDistribution.Weight(voter.ID())
+		if _, isAttacker := voter.(metastabilitybreaker.Attacker); isAttacker {
+			network.WeightDistribution.SetWeight(voter.ID(), weight/stats.AttackerWeight*stats.TotalWeight*share)
+		} else {
+			network.WeightDistribution.SetWeight(voter.ID(), weight/stats.HonestWeight*stats.TotalWeight*(1-share))
+		}
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
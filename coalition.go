@@ -0,0 +1,233 @@
+package metastabilitybreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iotaledger/hive.go/events"
+)
+
+// region AttackerCoalition ////////////////////////////////////////////////////////////////////////////////////////////
+
+// AttackerCoalition is an attacker that controls many identities (CoalitionMembers) with a split weight budget and
+// coordinates their votes according to the CoalitionRole of each member.
+type AttackerCoalition struct {
+	network          *Network
+	revealDelay      time.Duration
+	members          []*CoalitionMember
+	memberStatements map[VoterID]BranchID
+	perception       *CoalitionMember
+	firstHonestVote  time.Time
+	revealed         bool
+	nextLowerHash    int
+	mutex            sync.Mutex
+}
+
+// NewAttackerCoalition returns a new AttackerCoalition for the given Network. Its silent members stay silent until the
+// given revealDelay has passed since the first honest vote.
+func NewAttackerCoalition(network *Network, revealDelay time.Duration) *AttackerCoalition {
+	return &AttackerCoalition{
+		network:          network,
+		revealDelay:      revealDelay,
+		memberStatements: make(map[VoterID]BranchID),
+	}
+}
+
+// AddMembers adds the given amount of CoalitionMembers with the given role to the Network and splits the weightBudget
+// equally between them.
+func (a *AttackerCoalition) AddMembers(amount int, role CoalitionRole, weightBudget float64) {
+	a.network.AddVoters(amount, a.MemberFactory(role), UniformWeights(amount, weightBudget))
+}
+
+// MemberFactory returns a VoterFactory for CoalitionMembers with the given role.
+func (a *AttackerCoalition) MemberFactory(role CoalitionRole) VoterFactory {
+	return func(network *Network) Voter {
+		member := &CoalitionMember{
+			HonestVoter: NewHonestVoter(network).(*HonestVoter),
+			coalition:   a,
+			role:        role,
+		}
+
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+
+		a.members = append(a.members, member)
+		if a.perception == nil {
+			a.perception = member
+			member.ApprovalWeightManager().VoteProcessed.Attach(events.NewClosure(a.VoteProcessed))
+		}
+
+		return member
+	}
+}
+
+// Members returns the CoalitionMembers of the AttackerCoalition.
+func (a *AttackerCoalition) Members() (members []*CoalitionMember) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append(members, a.members...)
+}
+
+// VoteProcessed coordinates the reaction of the members to the votes of the honest Voters.
+func (a *AttackerCoalition) VoteProcessed(vote *Vote) {
+	if issuer, issuerExists := a.network.Voter(vote.Issuer); !issuerExists || issuer.Type() != "HonestVoter" {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.firstHonestVote.IsZero() {
+		a.firstHonestVote = a.network.Clock.Now()
+	}
+
+	largestBranch, secondLargestBranch := a.perception.consensus.CompetingBranches()
+	if secondLargestBranch == UndefinedBranchID {
+		return
+	}
+
+	votes := a.minorityVotes(largestBranch, secondLargestBranch)
+	votes = append(votes, a.lowerHashVotes(vote.BranchID)...)
+	votes = append(votes, a.revealVotes(secondLargestBranch)...)
+	for _, coalitionVote := range votes {
+		a.memberStatements[coalitionVote.Issuer] = coalitionVote.BranchID
+	}
+
+	a.network.schedule(0, func() {
+		for _, coalitionVote := range votes {
+			a.network.VoteReceived.Trigger(coalitionVote)
+		}
+	})
+}
+
+// minorityVotes moves as few minority members as necessary to the lighter of the two competing Branches to close the
+// gap between them.
+func (a *AttackerCoalition) minorityVotes(largestBranch, secondLargestBranch BranchID) (votes []*Vote) {
+	weightGap := a.perception.ApprovalWeightManager().Weight(largestBranch) - a.perception.ApprovalWeightManager().Weight(secondLargestBranch)
+	for _, member := range a.membersWithRole(MinorityRole) {
+		if weightGap <= 0 {
+			break
+		}
+
+		statement, hasVoted := a.memberStatements[member.ID()]
+		if hasVoted && statement == secondLargestBranch {
+			continue
+		}
+
+		memberWeight := a.network.WeightDistribution.Weight(member.ID())
+		if weightGap -= memberWeight; hasVoted && statement == largestBranch {
+			weightGap -= memberWeight
+		}
+
+		votes = append(votes, &Vote{Issuer: member.ID(), BranchID: secondLargestBranch})
+	}
+
+	return votes
+}
+
+// lowerHashVotes lets the next lower hash member (round-robin) introduce a Branch with a lower hash than the one that
+// the honest Voter voted for.
+func (a *AttackerCoalition) lowerHashVotes(honestBranch BranchID) (votes []*Vote) {
+	lowerHashMembers := a.membersWithRole(LowerHashRole)
+	if len(lowerHashMembers) == 0 {
+		return nil
+	}
+
+	member := lowerHashMembers[a.nextLowerHash%len(lowerHashMembers)]
+	a.nextLowerHash++
+
+	return []*Vote{{Issuer: member.ID(), BranchID: honestBranch.lowerHash()}}
+}
+
+// revealVotes lets all silent members vote for the lighter of the two competing Branches once the revealDelay passed.
+func (a *AttackerCoalition) revealVotes(secondLargestBranch BranchID) (votes []*Vote) {
+	if a.revealed || a.network.Clock.Now().Sub(a.firstHonestVote) < a.revealDelay {
+		return nil
+	}
+
+	silentMembers := a.membersWithRole(SilentRole)
+	if len(silentMembers) == 0 {
+		return nil
+	}
+
+	a.revealed = true
+	for _, member := range silentMembers {
+		votes = append(votes, &Vote{Issuer: member.ID(), BranchID: secondLargestBranch})
+	}
+
+	return votes
+}
+
+// membersWithRole returns the members that have the given role.
+func (a *AttackerCoalition) membersWithRole(role CoalitionRole) (members []*CoalitionMember) {
+	for _, member := range a.members {
+		if member.role == role {
+			members = append(members, member)
+		}
+	}
+
+	return members
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region CoalitionRole ////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CoalitionRole defines the strategy that a CoalitionMember follows.
+type CoalitionRole int
+
+const (
+	// MinorityRole is the role of members that vote for the lighter of the two competing Branches.
+	MinorityRole CoalitionRole = iota
+
+	// LowerHashRole is the role of members that keep introducing Branches with lower hashes.
+	LowerHashRole
+
+	// SilentRole is the role of members that withhold their weight and reveal it later.
+	SilentRole
+)
+
+func (c CoalitionRole) String() string {
+	switch c {
+	case MinorityRole:
+		return "Minority"
+	case LowerHashRole:
+		return "LowerHash"
+	case SilentRole:
+		return "Silent"
+	default:
+		return "Unknown"
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region CoalitionMember //////////////////////////////////////////////////////////////////////////////////////////////
+
+// CoalitionMember is one of the identities that are controlled by an AttackerCoalition.
+type CoalitionMember struct {
+	*HonestVoter
+
+	coalition *AttackerCoalition
+	role      CoalitionRole
+}
+
+// Role returns the CoalitionRole of the member.
+func (c *CoalitionMember) Role() CoalitionRole {
+	return c.role
+}
+
+func (c *CoalitionMember) SendVote() (opinionChanged bool) {
+	// do nothing, the coalition votes on behalf of its members
+	return false
+}
+
+func (c *CoalitionMember) Type() string {
+	return "Coalition" + c.role.String() + "Voter"
+}
+
+// IsAttacker marks the CoalitionMember as an Attacker.
+func (c *CoalitionMember) IsAttacker() {}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,140 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttackerCoalition_MetastabilityBreakerEnabled(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	defer network.Shutdown()
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	coalition := NewAttackerCoalition(network, 500*time.Millisecond)
+	coalition.AddMembers(4, MinorityRole, 0.1)
+	coalition.AddMembers(2, LowerHashRole, 0.05)
+	coalition.AddMembers(2, SilentRole, 0.05)
+	require.InDelta(t, 0.2, network.WeightDistributionStats().AttackerWeight, 1e-9)
+	require.NoError(t, network.ResolveConflicts(NewBranchID(1000), NewBranchID(1001)))
+
+	assert.Eventually(t, network.ConflictResolved, 10*time.Second, 50*time.Millisecond, "failed to resolve metastable state")
+}
+
+func TestAttackerCoalition_SingleIdentity(t *testing.T) {
+	// the coalition and the single MinorityVoter control the same total weight of 0.2
+	coalitionResult := runAttackBatch(t, 1*time.Second, 200, func(network *Network) {
+		network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+		coalition := NewAttackerCoalition(network, 500*time.Millisecond)
+		coalition.AddMembers(4, MinorityRole, 0.1)
+		coalition.AddMembers(2, LowerHashRole, 0.05)
+		coalition.AddMembers(2, SilentRole, 0.05)
+	})
+	singleIdentityResult := runAttackBatch(t, 1*time.Second, 200, func(network *Network) {
+		network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+		network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+	})
+	t.Logf("coalition:\n%s\nsingle identity:\n%s", coalitionResult, singleIdentityResult)
+
+	for _, result := range []*BatchResult{coalitionResult, singleIdentityResult} {
+		require.Equal(t, result.Runs, result.ResolvedRuns)
+
+		_, lowerBound, _ := result.ResolvedWithin(1500 * time.Millisecond)
+		assert.GreaterOrEqual(t, lowerBound, 0.95)
+	}
+}
+
+func TestAttackerCoalition_RoleVotes(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	coalition := NewAttackerCoalition(network, 500*time.Millisecond)
+	coalition.AddMembers(2, MinorityRole, 0.1)
+	coalition.AddMembers(2, LowerHashRole, 0.05)
+	coalition.AddMembers(1, SilentRole, 0.05)
+
+	honestVoters, members := network.Voters()[:8], coalition.Members()
+	honestVote := func(honestVoter Voter, branchID BranchID) {
+		network.VoteReceived.Trigger(&Vote{Issuer: honestVoter.ID(), BranchID: branchID})
+		network.Clock.Sleep(0)
+	}
+	assertStatements := func(expectedStatements map[int]BranchID) {
+		for i, member := range members {
+			statement, exists := honestVoters[0].ApprovalWeightManager().LastStatement(member.ID())
+			if expectedStatement, expected := expectedStatements[i]; expected {
+				assert.True(t, exists, "%s %d should have voted", member.Type(), i)
+				assert.Equal(t, expectedStatement, statement, "%s %d voted for the wrong Branch", member.Type(), i)
+			} else {
+				assert.False(t, exists, "%s %d should not have voted", member.Type(), i)
+			}
+		}
+	}
+
+	// the coalition waits until there are two competing Branches
+	honestVote(honestVoters[0], NewBranchID(1))
+	assertStatements(map[int]BranchID{})
+
+	// the Branches are equally heavy, so only the first lower hash member introduces a Branch with a lower hash
+	honestVote(honestVoters[1], NewBranchID(2))
+	assertStatements(map[int]BranchID{2: NewBranchID(1)})
+
+	// both minority members are needed to close the gap of 0.125 and the second lower hash member skips the
+	// UndefinedBranchID
+	honestVote(honestVoters[2], NewBranchID(1))
+	assertStatements(map[int]BranchID{0: NewBranchID(2), 1: NewBranchID(2), 2: NewBranchID(1), 3: NewBranchID(-1)})
+
+	// the silent member reveals its weight for the lighter Branch once the reveal delay passed since the first honest
+	// vote, and the lower hash members take turns
+	network.Clock.Sleep(500 * time.Millisecond)
+	honestVote(honestVoters[3], NewBranchID(1))
+	assertStatements(map[int]BranchID{0: NewBranchID(2), 1: NewBranchID(2), 2: NewBranchID(-1), 3: NewBranchID(-1), 4: NewBranchID(2)})
+	assert.InDelta(t, 0.3, honestVoters[0].ApprovalWeightManager().Weight(NewBranchID(1)), 1e-9)
+	assert.InDelta(t, 0.25, honestVoters[0].ApprovalWeightManager().Weight(NewBranchID(2)), 1e-9)
+}
+
+func TestAttackerCoalition_SilentMembersReveal(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	defer network.Shutdown()
+	network.AddVoters(9, NewHonestVoter, FixedWeight(0.1))
+	coalition := NewAttackerCoalition(network, 300*time.Millisecond)
+	coalition.AddMembers(2, SilentRole, 0.1)
+	require.NoError(t, network.ResolveConflicts(NewBranchID(1), NewBranchID(2)))
+
+	honestVoter := network.Voters()[0]
+	for _, member := range coalition.Members() {
+		_, revealed := honestVoter.ApprovalWeightManager().LastStatement(member.ID())
+		assert.False(t, revealed, "silent members should not vote before the reveal delay")
+		assert.Equal(t, "CoalitionSilentVoter", member.Type())
+	}
+
+	assert.Eventually(t, func() bool {
+		for _, member := range coalition.Members() {
+			if _, revealed := honestVoter.ApprovalWeightManager().LastStatement(member.ID()); !revealed {
+				return false
+			}
+		}
+
+		return true
+	}, 5*time.Second, 50*time.Millisecond, "silent members should reveal their weight")
+}
+
+func TestAttackerCoalition_LowestBranches(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(1)
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	coalition := NewAttackerCoalition(network, 500*time.Millisecond)
+	coalition.AddMembers(2, LowerHashRole, 0.2)
+
+	_, _, err := network.Simulate(2*time.Second, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+
+	honestVoter := network.Voters()[0]
+	for _, member := range coalition.Members() {
+		introducedBranch, introduced := honestVoter.ApprovalWeightManager().LastStatement(member.ID())
+		require.True(t, introduced, "lower hash members should introduce Branches")
+		assert.NotEqual(t, UndefinedBranchID, introducedBranch, "the introduced Branch should not be the UndefinedBranchID")
+		assert.Less(t, int(introducedBranch), 1, "the introduced Branch should have a lower hash than the lowest Branch")
+	}
+}
@@ -5,27 +5,49 @@ import (
 	"time"
 )
 
-const (
-	confirmationThreshold = 0.66
-)
-
 // region Consensus ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 type Consensus struct {
-	voter Voter
-	timeOffset time.Duration
+	voter            Voter
+	weightDeviations *weightDeviations
+
+	// exactPerception disables the PerceptionNoise for Voters that are not part of the Network (e.g. the replayed
+	// Attackers of a trace).
+	exactPerception bool
 }
 
 func NewConsensus(voter Voter) *Consensus {
 	return &Consensus{
-		voter: voter,
+		voter:            voter,
+		weightDeviations: newWeightDeviations(voter.Network()),
 	}
 }
 
 func (c *Consensus) CompetingBranches() (largestBranch, secondLargestBranch BranchID) {
+	return c.competingBranches(c.livePerception())
+}
+
+// CompetingBranchesAt returns the two heaviest Branches of the given Snapshot.
+func (c *Consensus) CompetingBranchesAt(snapshot *Snapshot) (largestBranch, secondLargestBranch BranchID) {
+	return c.competingBranches(snapshot)
+}
+
+func (c *Consensus) FavoredBranch() BranchID {
+	return c.favoredBranch(c.livePerception(), c.voter.Network().Clock.Now())
+}
+
+// FavoredBranchAt returns the Branch that the Voter would favor if it had the perception of the given Snapshot at the
+// given time. It does not modify the live state of the Voter.
+func (c *Consensus) FavoredBranchAt(snapshot *Snapshot, now time.Time) BranchID {
+	return c.favoredBranch(snapshot, now)
+}
+
+// competingBranches returns the two heaviest Branches of the given perception. The Branches are iterated in ascending
+// order, so ties are broken deterministically (the Branch with the higher BranchID counts as the heavier one).
+func (c *Consensus) competingBranches(perception Perception) (largestBranch, secondLargestBranch BranchID) {
 	var largestBranchWeight, secondLargestBranchWeight float64
-	for branchID := range c.voter.BranchManager().BranchIDs() {
-		branchWeight := c.voter.ApprovalWeightManager().Weight(branchID)
+	for _, branchID := range perception.BranchIDs().Sorted() {
+		branchWeight := perception.Weight(branchID)
 		if branchWeight >= largestBranchWeight {
 			secondLargestBranch = largestBranch
 			secondLargestBranchWeight = largestBranchWeight
@@ -41,44 +63,145 @@ func (c *Consensus) CompetingBranches() (largestBranch, secondLargestBranch Bran
 	return
 }
 
-func (c *Consensus) FavoredBranch() BranchID {
-	heaviestBranch, secondHeaviestBranch := c.CompetingBranches()
-	if heaviestBranch == UndefinedBranchID || secondHeaviestBranch == UndefinedBranchID {
-		return heaviestBranch
+func (c *Consensus) favoredBranch(perception Perception, now time.Time) BranchID {
+	consensusRule := c.voter.Network().ConsensusRule
+	if consensusRule == nil {
+		consensusRule = MetastabilityBreakerRule()
+	}
+
+	return consensusRule(c, perception, now)
+}
+
+func (c *Consensus) deltaWeight(perception Perception, branch1ID, branch2ID BranchID) float64 {
+	return math.Abs(perception.Weight(branch1ID) - perception.Weight(branch2ID))
+}
+
+func (c *Consensus) pendingTime(perception Perception, now time.Time, branch1ID, branch2ID BranchID) time.Duration {
+	branch1SolidificationTime := perception.SolidificationTime(branch1ID)
+	branch2SolidificationTime := perception.SolidificationTime(branch2ID)
+
+	if branch1SolidificationTime.After(branch2SolidificationTime) {
+		return now.Sub(branch1SolidificationTime)
+	}
+
+	return now.Sub(branch2SolidificationTime)
+}
+
+func (c *Consensus) timeScaling(perception Perception, now time.Time, branch1ID, branch2ID BranchID) float64 {
+	timeScaling := c.voter.Network().TimeScaling
+	if timeScaling == nil {
+		timeScaling = LinearTimeScaling()
+	}
+
+	return timeScaling(float64(c.pendingTime(perception, now, branch1ID, branch2ID).Nanoseconds()) / float64(c.voter.Network().MetastabilityBreakingThreshold.Nanoseconds()))
+}
+
+// livePerception returns the perception that reflects the current state of the Voter (including the PerceptionNoise
+// of the Network if the Voter is not an Attacker).
+func (c *Consensus) livePerception() Perception {
+	voterPerception := &voterPerception{
+		voter: c.voter,
+	}
+	if !c.perceivesWeightDeviations() {
+		return voterPerception
 	}
 
-	if c.voter.Network().MetastabilityBreakingThreshold != 0 && c.deltaWeight(heaviestBranch, secondHeaviestBranch) <= c.timeScaling(heaviestBranch, secondHeaviestBranch)*confirmationThreshold {
-		if heaviestBranch < secondHeaviestBranch {
+	return &noisyPerception{
+		Perception:       voterPerception,
+		weightDeviations: c.weightDeviations,
+	}
+}
+
+// drawWeightDeviations draws the perceived weight deviations of all Branches that the Voter knows, so that inspecting
+// its opinion afterwards (e.g. by a Tracer) does not consume the randomness of the Network.
+func (c *Consensus) drawWeightDeviations() {
+	if !c.perceivesWeightDeviations() {
+		return
+	}
+
+	for _, branchID := range c.voter.BranchManager().BranchIDs().Sorted() {
+		c.weightDeviations.Deviation(branchID)
+	}
+}
+
+// perceivesWeightDeviations returns true if the Voter perceives the weights with the MaxWeightDeviation of the
+// PerceptionNoise of the Network.
+func (c *Consensus) perceivesWeightDeviations() bool {
+	return c.voter.Network().PerceptionNoise.MaxWeightDeviation != 0 && !c.exactPerception && c.voter.Network().perceivesNoise(c.voter.ID())
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region ConsensusRule ////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ConsensusRule decides which Branch a Voter favors given its Perception at the given time.
+type ConsensusRule func(consensus *Consensus, perception Perception, now time.Time) BranchID
+
+// MetastabilityBreakerRule returns the ConsensusRule that favors the heavier of the two competing Branches unless their
+// weight gap is within the lower hash window of the metastability breaker, in which case it favors the lower hash.
+func MetastabilityBreakerRule() ConsensusRule {
+	return func(c *Consensus, perception Perception, now time.Time) BranchID {
+		heaviestBranch, secondHeaviestBranch := c.competingBranches(perception)
+		if heaviestBranch == UndefinedBranchID || secondHeaviestBranch == UndefinedBranchID {
+			return heaviestBranch
+		}
+
+		if c.voter.Network().MetastabilityBreakingThreshold != 0 && c.deltaWeight(perception, heaviestBranch, secondHeaviestBranch) <= c.timeScaling(perception, now, heaviestBranch, secondHeaviestBranch)*c.voter.Network().BreakerCeiling {
+			if heaviestBranch < secondHeaviestBranch {
+				return heaviestBranch
+			}
+
+			return secondHeaviestBranch
+		}
+
+		if perception.Weight(heaviestBranch) > perception.Weight(secondHeaviestBranch) {
 			return heaviestBranch
 		}
 
 		return secondHeaviestBranch
 	}
+}
+
+// HeaviestBranchRule returns the ConsensusRule that always favors the heavier of the two competing Branches (ignoring
+// the metastability breaker).
+func HeaviestBranchRule() ConsensusRule {
+	return func(c *Consensus, perception Perception, now time.Time) BranchID {
+		heaviestBranch, secondHeaviestBranch := c.competingBranches(perception)
+		if secondHeaviestBranch != UndefinedBranchID && perception.Weight(heaviestBranch) <= perception.Weight(secondHeaviestBranch) {
+			return secondHeaviestBranch
+		}
 
-	if c.voter.ApprovalWeightManager().Weight(heaviestBranch) > c.voter.ApprovalWeightManager().Weight(secondHeaviestBranch) {
 		return heaviestBranch
 	}
-
-	return secondHeaviestBranch
 }
 
-func (c *Consensus) deltaWeight(branch1ID, branch2ID BranchID) float64 {
-	return math.Abs(c.voter.ApprovalWeightManager().Weight(branch1ID) - c.voter.ApprovalWeightManager().Weight(branch2ID))
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region Perception ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Perception represents the information about the Branches that the Consensus bases its decisions on. It is either the
+// live state of a Voter or a Snapshot of it.
+type Perception interface {
+	BranchIDs() BranchIDs
+	SolidificationTime(branchID BranchID) time.Time
+	Weight(branchID BranchID) float64
 }
 
-func (c *Consensus) pendingTime(branch1ID, branch2ID BranchID) time.Duration {
-	branch1SolidificationTime := c.voter.BranchManager().Metadata(branch1ID).SolidificationTime
-	branch2SolidificationTime := c.voter.BranchManager().Metadata(branch2ID).SolidificationTime
+// voterPerception is the perception that reads the live state of a Voter.
+type voterPerception struct {
+	voter Voter
+}
 
-	if branch1SolidificationTime.After(branch2SolidificationTime) {
-		return time.Now().Add(c.timeOffset).Sub(branch1SolidificationTime)
-	}
+func (v *voterPerception) BranchIDs() BranchIDs {
+	return v.voter.BranchManager().BranchIDs()
+}
 
-	return time.Now().Add(c.timeOffset).Sub(branch2SolidificationTime)
+func (v *voterPerception) SolidificationTime(branchID BranchID) time.Time {
+	return v.voter.BranchManager().Metadata(branchID).SolidificationTime
 }
 
-func (c *Consensus) timeScaling(branch1ID, branch2ID BranchID) float64 {
-	return math.Min(float64(c.pendingTime(branch1ID, branch2ID).Nanoseconds())/float64(c.voter.Network().MetastabilityBreakingThreshold.Nanoseconds()), 1)
+func (v *voterPerception) Weight(branchID BranchID) float64 {
+	return v.voter.ApprovalWeightManager().Weight(branchID)
 }
 
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
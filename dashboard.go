@@ -0,0 +1,212 @@
+package metastabilitybreaker
+
+import (
+	// the page of the Dashboard is embedded into the binary
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// region Dashboard ////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Dashboard is an http.Handler that shows the state of a running Network in the browser. It serves a page on / that
+// subscribes to a stream of Server-Sent Events on /events, which pushes a DashboardState in a fixed interval of wall
+// clock time (so it is mostly useful for simulations that run in real time). The current DashboardState can also be
+// fetched as JSON from /state.
+type Dashboard struct {
+	network   *Network
+	interval  time.Duration
+	startTime time.Time
+	serveMux  *http.ServeMux
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDashboard returns a Dashboard that streams the state of the given Network in the given interval.
+func NewDashboard(network *Network, interval time.Duration) (dashboard *Dashboard) {
+	dashboard = &Dashboard{
+		network:   network,
+		interval:  interval,
+		startTime: network.Clock.Now(),
+		serveMux:  http.NewServeMux(),
+		closed:    make(chan struct{}),
+	}
+
+	dashboard.serveMux.HandleFunc("/", dashboard.servePage)
+	dashboard.serveMux.HandleFunc("/state", dashboard.serveState)
+	dashboard.serveMux.HandleFunc("/events", dashboard.serveEvents)
+
+	return dashboard
+}
+
+// ServeHTTP serves the page, the current state and the stream of states of the Dashboard.
+func (d *Dashboard) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	d.serveMux.ServeHTTP(responseWriter, request)
+}
+
+// Close ends all open event streams.
+func (d *Dashboard) Close() {
+	d.closeOnce.Do(func() {
+		close(d.closed)
+	})
+}
+
+// State returns the current state of the Network.
+func (d *Dashboard) State() (state *DashboardState) {
+	now := d.network.Clock.Now()
+	sample := newTimeSeriesSample(d.network, now)
+
+	state = &DashboardState{
+		Time:                now.Sub(d.startTime).Seconds(),
+		BranchWeights:       sample.BranchWeights,
+		LargestBranch:       sample.LargestBranch,
+		SecondLargestBranch: sample.SecondLargestBranch,
+		WeightGap:           sample.WeightGap,
+		BreakerThreshold:    sample.BreakerThreshold,
+		ConflictResolved:    d.network.ConflictResolved(),
+		Voters:              make([]*DashboardVoter, 0),
+	}
+
+	for _, voter := range d.network.Voters() {
+		snapshot := NewSnapshot(voter)
+		dashboardVoter := &DashboardVoter{
+			ID:            voter.ID(),
+			Type:          voter.Type(),
+			Weight:        d.network.WeightDistribution.Weight(voter.ID()),
+			Online:        voter.Online(),
+			Statement:     sample.Opinions[voter.ID()].BranchID,
+			BranchWeights: make(map[BranchID]float64),
+		}
+		for branchID := range snapshot.BranchIDs() {
+			dashboardVoter.BranchWeights[branchID] = snapshot.Weight(branchID)
+		}
+
+		state.Voters = append(state.Voters, dashboardVoter)
+	}
+	sort.Slice(state.Voters, func(i, j int) bool { return state.Voters[i].ID < state.Voters[j].ID })
+
+	return state
+}
+
+// servePage serves the page that renders the stream of states.
+func (d *Dashboard) servePage(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(responseWriter, request)
+
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "text/html; charset=utf-8")
+	responseWriter.Write(dashboardPage)
+}
+
+// serveState serves the current state as JSON.
+func (d *Dashboard) serveState(responseWriter http.ResponseWriter, _ *http.Request) {
+	data, err := json.Marshal(d.State())
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.Write(data)
+}
+
+// serveEvents streams the state as Server-Sent Events until the client disconnects or the Dashboard is closed.
+func (d *Dashboard) serveEvents(responseWriter http.ResponseWriter, request *http.Request) {
+	flusher, ok := responseWriter.(http.Flusher)
+	if !ok {
+		http.Error(responseWriter, "streaming is not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "text/event-stream")
+	responseWriter.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		data, err := json.Marshal(d.State())
+		if err != nil {
+			return
+		}
+		if _, err = fmt.Fprintf(responseWriter, "event: state\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-ticker.C:
+		case <-request.Context().Done():
+			return
+		case <-d.closed:
+			return
+		}
+	}
+}
+
+// dashboardPage is the page that renders the stream of states.
+//
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region DashboardState ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// DashboardState is the state of a Network that the Dashboard streams.
+type DashboardState struct {
+	// Time is the (simulated) time in seconds since the Dashboard was created.
+	Time float64 `json:"time"`
+
+	// BranchWeights are the weights of the Branches per voter type (see Network.ApprovalWeightByVoterType).
+	BranchWeights map[string]map[BranchID]float64 `json:"branchWeights"`
+
+	// LargestBranch is the heavier of the two competing Branches.
+	LargestBranch BranchID `json:"largestBranch"`
+
+	// SecondLargestBranch is the lighter of the two competing Branches.
+	SecondLargestBranch BranchID `json:"secondLargestBranch"`
+
+	// WeightGap is the difference between the weights of the two competing Branches.
+	WeightGap float64 `json:"weightGap"`
+
+	// BreakerThreshold is the weight gap up to which the metastability breaker favors the lower hash.
+	BreakerThreshold float64 `json:"breakerThreshold"`
+
+	// ConflictResolved is true if all online HonestVoters agree on the same Branch.
+	ConflictResolved bool `json:"conflictResolved"`
+
+	// Voters are the Voters of the Network ordered by their identifier.
+	Voters []*DashboardVoter `json:"voters"`
+}
+
+// DashboardVoter is the state of a single Voter.
+type DashboardVoter struct {
+	// ID is the identifier of the Voter.
+	ID VoterID `json:"id"`
+
+	// Type is the type of the Voter.
+	Type string `json:"type"`
+
+	// Weight is the weight of the Voter.
+	Weight float64 `json:"weight"`
+
+	// Online is false while a SleepyFault keeps the Voter offline.
+	Online bool `json:"online"`
+
+	// Statement is the Branch that the Voter last voted for (UndefinedBranchID if it did not vote yet).
+	Statement BranchID `json:"statement"`
+
+	// BranchWeights are the weights of the Branches in the ApprovalWeightManager of the Voter.
+	BranchWeights map[BranchID]float64 `json:"branchWeights"`
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,90 @@
+package metastabilitybreaker
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	network := NewNetwork(5 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(1)
+	defer network.Shutdown()
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+
+	dashboard := NewDashboard(network, 10*time.Millisecond)
+	server := httptest.NewServer(dashboard)
+	defer server.Close()
+	defer dashboard.Close()
+
+	page := getBody(t, server, "/")
+	assert.Contains(t, page, "EventSource(\"events\")")
+	response, err := server.Client().Get(server.URL + "/unknown")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	state := &DashboardState{}
+	require.NoError(t, json.Unmarshal([]byte(getBody(t, server, "/state")), state))
+	require.Len(t, state.Voters, 9)
+	assert.Equal(t, "HonestVoter", state.Voters[0].Type)
+	assert.Equal(t, UndefinedBranchID, state.Voters[0].Statement, "no Voter voted before the start")
+	assert.Equal(t, "MinorityVoter", state.Voters[8].Type)
+	assert.InDelta(t, 0.2, state.Voters[8].Weight, 1e-9)
+
+	resolved, _, err := network.Simulate(time.Minute, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+	require.True(t, resolved)
+
+	response, err = server.Client().Get(server.URL + "/events")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	for i := 0; i < 2; i++ {
+		event, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "event: state\n", event)
+
+		data, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		state = &DashboardState{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), state))
+		assert.True(t, state.ConflictResolved)
+		assert.Equal(t, state.Voters[0].Statement, state.Voters[1].Statement)
+		assert.InDelta(t, 0.8, state.Voters[0].BranchWeights[state.Voters[0].Statement], 1e-9)
+
+		blank, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "\n", blank)
+	}
+
+	// closing the Dashboard ends the stream
+	dashboard.Close()
+	_, err = ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+}
+
+// getBody returns the body of a GET request to the given path of the server.
+func getBody(t *testing.T, server *httptest.Server, path string) string {
+	response, err := server.Client().Get(server.URL + path)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
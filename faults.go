@@ -0,0 +1,127 @@
+package metastabilitybreaker
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// region FaultModel ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FaultModel represents a generic interface for the different kinds of availability faults that a Voter can suffer.
+type FaultModel interface {
+	// Online returns true if the Voter is online at the given time.
+	Online(now time.Time) bool
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region CrashStopFault ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// CrashStopFault is a FaultModel for Voters that crash at a given time and never come back online.
+type CrashStopFault struct {
+	crashTime time.Time
+}
+
+// NewCrashStopFault returns a new CrashStopFault that crashes at the given time.
+func NewCrashStopFault(crashTime time.Time) *CrashStopFault {
+	return &CrashStopFault{
+		crashTime: crashTime,
+	}
+}
+
+// Online returns true if the Voter did not crash yet.
+func (c *CrashStopFault) Online(now time.Time) bool {
+	return now.Before(c.crashTime)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region SleepyFault //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// minSleepyInterval defines the minimum duration of an online or offline period of a SleepyFault.
+const minSleepyInterval = time.Millisecond
+
+// SleepyFault is a FaultModel for Voters that alternate between online and offline periods of random (exponentially
+// distributed) length.
+type SleepyFault struct {
+	meanOnlineTime  time.Duration
+	meanOfflineTime time.Duration
+	random          *rand.Rand
+	online          bool
+	nextToggle      time.Time
+	mutex           sync.Mutex
+}
+
+// NewSleepyFault returns a new SleepyFault with the given mean durations of the online and offline periods that draws
+// the length of the periods from the given source of randomness. The Voter starts online when it is queried for its
+// availability for the first time.
+func NewSleepyFault(meanOnlineTime, meanOfflineTime time.Duration, random *rand.Rand) *SleepyFault {
+	return &SleepyFault{
+		meanOnlineTime:  meanOnlineTime,
+		meanOfflineTime: meanOfflineTime,
+		random:          random,
+	}
+}
+
+// Online returns true if the Voter is in an online period at the given time.
+func (s *SleepyFault) Online(now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.nextToggle.IsZero() {
+		s.online = true
+		s.nextToggle = now.Add(s.randomInterval(s.meanOnlineTime))
+	}
+
+	for !now.Before(s.nextToggle) {
+		s.online = !s.online
+
+		if s.online {
+			s.nextToggle = s.nextToggle.Add(s.randomInterval(s.meanOnlineTime))
+		} else {
+			s.nextToggle = s.nextToggle.Add(s.randomInterval(s.meanOfflineTime))
+		}
+	}
+
+	return s.online
+}
+
+// randomInterval returns an exponentially distributed duration with the given mean.
+func (s *SleepyFault) randomInterval(mean time.Duration) (interval time.Duration) {
+	if interval = time.Duration(s.random.ExpFloat64() * float64(mean)); interval < minSleepyInterval {
+		return minSleepyInterval
+	}
+
+	return interval
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region VoterFactories ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewCrashFaultyVoter returns a VoterFactory for HonestVoters that crash after the given amount of time has passed on
+// the Clock of the Network since they were created.
+func NewCrashFaultyVoter(crashAfter time.Duration) VoterFactory {
+	return func(network *Network) Voter {
+		return NewFaultyHonestVoter(network, NewCrashStopFault(network.Clock.Now().Add(crashAfter)))
+	}
+}
+
+// NewSleepyVoter returns a VoterFactory for HonestVoters that go offline for random intervals and catch up with the
+// votes that they missed when they come back online.
+func NewSleepyVoter(meanOnlineTime, meanOfflineTime time.Duration) VoterFactory {
+	return func(network *Network) Voter {
+		return NewFaultyHonestVoter(network, NewSleepyFault(meanOnlineTime, meanOfflineTime, network.Random))
+	}
+}
+
+// NewFaultyHonestVoter returns a new HonestVoter whose availability is determined by the given FaultModel.
+func NewFaultyHonestVoter(network *Network, faultModel FaultModel) Voter {
+	honestVoter := NewHonestVoter(network).(*HonestVoter)
+	honestVoter.faultModel = faultModel
+
+	return honestVoter
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
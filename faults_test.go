@@ -0,0 +1,72 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashStopFault(t *testing.T) {
+	startTime := time.Now()
+	crashStopFault := NewCrashStopFault(startTime.Add(time.Second))
+
+	assert.True(t, crashStopFault.Online(startTime))
+	assert.True(t, crashStopFault.Online(startTime.Add(999*time.Millisecond)))
+	assert.False(t, crashStopFault.Online(startTime.Add(time.Second)))
+	assert.False(t, crashStopFault.Online(startTime.Add(time.Hour)))
+}
+
+func TestSleepyFault(t *testing.T) {
+	startTime := time.Now()
+	sleepyFault := NewSleepyFault(100*time.Millisecond, 100*time.Millisecond, NewRandom(0))
+
+	var onlinePeriods, offlinePeriods int
+	for wasOnline, elapsed := false, time.Duration(0); elapsed < time.Minute; elapsed += 10 * time.Millisecond {
+		online := sleepyFault.Online(startTime.Add(elapsed))
+		if elapsed == 0 {
+			assert.True(t, online, "voter should start online")
+		}
+
+		if online && !wasOnline {
+			onlinePeriods++
+		} else if !online && wasOnline {
+			offlinePeriods++
+		}
+		wasOnline = online
+	}
+
+	assert.Greater(t, onlinePeriods, 10)
+	assert.Greater(t, offlinePeriods, 10)
+}
+
+func TestSleepyVoter_CatchUp(t *testing.T) {
+	network := NewNetwork(0)
+	sleepyVoter := NewFaultyHonestVoter(network, NewCrashStopFault(time.Now()))
+
+	issuer := NewVoterID()
+	sleepyVoter.OnVoteReceived(&Vote{Issuer: issuer, BranchID: NewBranchID(2)})
+	sleepyVoter.OnVoteReceived(&Vote{Issuer: issuer, BranchID: NewBranchID(1)})
+	assert.False(t, sleepyVoter.SendVote())
+	assert.Empty(t, sleepyVoter.ApprovalWeightManager().LastStatements())
+	assert.Len(t, sleepyVoter.(*HonestVoter).missedVotes, 1, "only the latest missed vote of an issuer should be kept")
+
+	sleepyVoter.(*HonestVoter).faultModel = nil
+	sleepyVoter.SendVote()
+	lastStatement, exists := sleepyVoter.ApprovalWeightManager().LastStatement(issuer)
+	assert.True(t, exists, "missed vote should be processed when coming back online")
+	assert.Equal(t, NewBranchID(1), lastStatement)
+}
+
+func TestCrashFaultyVoter_MetastabilityBreakerEnabled(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	defer network.Shutdown()
+	network.AddVoters(6, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(2, NewCrashFaultyVoter(500*time.Millisecond), FixedWeight(0.1))
+	network.AddVoters(1, NewSleepyVoter(300*time.Millisecond, 300*time.Millisecond), FixedWeight(0.05))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.15))
+	require.NoError(t, network.ResolveConflicts(NewBranchID(1), NewBranchID(2)))
+
+	assert.Eventually(t, network.ConflictResolved, 10*time.Second, 50*time.Millisecond, "failed to resolve metastable state")
+}
@@ -2,7 +2,10 @@ package metastabilitybreaker
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"sort"
 	"strings"
 	"sync"
@@ -17,18 +20,78 @@ import (
 
 // region Network //////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// voteInterval defines the time that passes after a Voter changed its opinion before the next Voter gets to vote.
+const voteInterval = 100 * time.Millisecond
+
+// weightTolerance defines the maximum deviation from 1.0 that the total weight of all Voters is allowed to have.
+const weightTolerance = 1e-6
+
+const (
+	// DefaultConfirmationThreshold is the default share of the approval weight at which a Branch is considered
+	// confirmed.
+	DefaultConfirmationThreshold = 0.66
+
+	// DefaultBreakerCeiling is the default weight gap up to which the metastability breaker prefers the lower hash once
+	// the MetastabilityBreakingThreshold is reached.
+	DefaultBreakerCeiling = 0.66
+)
+
+// ErrInvalidWeightDistribution is returned when the weights of the Voters do not form a valid distribution.
+var ErrInvalidWeightDistribution = errors.New("invalid weight distribution")
+
+// ErrInvalidParameter is returned when a configuration parameter of the Network is out of range.
+var ErrInvalidParameter = errors.New("invalid parameter")
+
 type Network struct {
 	MetastabilityBreakingThreshold time.Duration
-	Voters                         map[VoterID]Voter
-	BeforeNextVote                 *events.Event
-	VoteReceived                   *events.Event
-	WeightDistribution             *WeightDistribution
+	// ConfirmationThreshold defines the share of the approval weight at which a Branch is considered confirmed.
+	ConfirmationThreshold float64
+	// BreakerCeiling defines the weight gap up to which the lower hash is preferred once the
+	// MetastabilityBreakingThreshold is reached (the lower hash window grows from 0 to this value over time).
+	BreakerCeiling float64
+	// StatementTTL defines the time after which the statement of an issuer stops counting unless it is refreshed (0
+	// disables the expiry).
+	StatementTTL time.Duration
+	// HeartbeatInterval defines the interval in which HonestVoters repeat their statement even if their opinion did not
+	// change (0 disables the heartbeats).
+	HeartbeatInterval time.Duration
+	// PerceptionNoise defines how much the perception of the honest Voters diverges (the zero value disables the
+	// noise).
+	PerceptionNoise PerceptionNoise
+	// TimeScaling defines the curve that widens the lower hash window of the metastability breaker over time (nil
+	// defaults to the LinearTimeScaling).
+	TimeScaling TimeScaling
+	// ConsensusRule decides which Branch the Voters favor (nil defaults to the MetastabilityBreakerRule).
+	ConsensusRule ConsensusRule
+	// Clock is the source of time of the Network (a SimulatedClock allows to run simulations faster than real time).
+	Clock Clock
+	// Random is the source of randomness of the Network (seeding it makes simulations reproducible).
+	Random             *rand.Rand
+	BeforeNextVote     *events.Event
+	VoteReceived       *events.Event
+	WeightDistribution *WeightDistribution
+	// VoteDelivered is triggered when a Vote that was sent through the Network reached a Voter.
+	VoteDelivered *events.Event
+	// VoteProcessed is triggered when an HonestVoter (or an attacker that builds on top of it) applied a Vote to its
+	// ApprovalWeightManager (after the MaxUpdateDelay or once it came back online).
+	VoteProcessed *events.Event
+
+	voters        map[VoterID]Voter
+	voterClosures map[VoterID]map[*events.Event]*events.Closure
+	votersMutex   sync.RWMutex
+	running       bool
+	shutdown      chan struct{}
+	shutdownOnce  sync.Once
+	inFlightMutex sync.RWMutex
 }
 
 func NewNetwork(metastabilityBreakingThreshold time.Duration) *Network {
 	return &Network{
 		MetastabilityBreakingThreshold: metastabilityBreakingThreshold,
-		Voters:                         make(map[VoterID]Voter),
+		ConfirmationThreshold:          DefaultConfirmationThreshold,
+		BreakerCeiling:                 DefaultBreakerCeiling,
+		Clock:                          RealClock{},
+		Random:                         NewRandom(time.Now().UnixNano()),
 		WeightDistribution:             NewWeightDistribution(),
 		BeforeNextVote: events.NewEvent(func(handler interface{}, params ...interface{}) {
 			handler.(func(Voter))(params[0].(Voter))
@@ -36,21 +99,199 @@ func NewNetwork(metastabilityBreakingThreshold time.Duration) *Network {
 		VoteReceived: events.NewEvent(func(handler interface{}, params ...interface{}) {
 			handler.(func(*Vote))(params[0].(*Vote))
 		}),
+		VoteDelivered: events.NewEvent(func(handler interface{}, params ...interface{}) {
+			handler.(func(Voter, *Vote))(params[0].(Voter), params[1].(*Vote))
+		}),
+		VoteProcessed: events.NewEvent(func(handler interface{}, params ...interface{}) {
+			handler.(func(*HonestVoter, *Vote))(params[0].(*HonestVoter), params[1].(*Vote))
+		}),
+
+		voters:        make(map[VoterID]Voter),
+		voterClosures: make(map[VoterID]map[*events.Event]*events.Closure),
+		shutdown:      make(chan struct{}),
 	}
 }
 
-func (n *Network) AddVoters(amount int, voterFactory VoterFactory, weightGenerator func(voterID VoterID) float64) {
+// AddVoters adds the given amount of Voters to the Network. Voters that join while the Network is already running
+// bootstrap their perception of the Branches and the cast statements from one of their peers.
+func (n *Network) AddVoters(amount int, voterFactory VoterFactory, weightGenerator WeightGenerator) {
 	for i := 0; i < amount; i++ {
 		voter := voterFactory(n)
-
-		n.Voters[voter.ID()] = voter
 		n.WeightDistribution.SetWeight(voter.ID(), weightGenerator(voter.ID()))
 
-		n.VoteReceived.Attach(events.NewClosure(voter.OnVoteReceived))
+		n.votersMutex.Lock()
+		peer := n.bootstrapPeer()
+		n.voters[voter.ID()] = voter
+		n.votersMutex.Unlock()
+
+		n.attachVoterHandler(voter.ID(), n.VoteReceived, n.deliverTo(voter))
+
+		if peer != nil {
+			voter.BranchManager().Bootstrap(peer.BranchManager())
+			voter.ApprovalWeightManager().Bootstrap(peer.ApprovalWeightManager())
+		}
+	}
+}
+
+// deliverTo returns the handler that delivers the Votes that are sent through the Network to the given Voter (after
+// the delivery delay that the issuer of a Vote chose for the Voter).
+func (n *Network) deliverTo(voter Voter) func(vote *Vote) {
+	return func(vote *Vote) {
+		if vote.deliveryDelay == nil {
+			n.deliver(voter, vote)
+
+			return
+		}
+
+		if deliveryDelay := vote.deliveryDelay(voter); deliveryDelay > 0 {
+			n.schedule(deliveryDelay, func() {
+				if _, exists := n.Voter(voter.ID()); exists {
+					n.deliver(voter, vote)
+				}
+			})
+
+			return
+		}
+
+		n.deliver(voter, vote)
+	}
+}
+
+// deliver hands the given Vote to the given Voter.
+func (n *Network) deliver(voter Voter, vote *Vote) {
+	n.VoteDelivered.Trigger(voter, vote)
+	voter.OnVoteReceived(vote)
+}
+
+// attachVoterHandler attaches the given handler of the Voter with the given identifier to the given event, so that it
+// is detached again when the Voter leaves the Network.
+func (n *Network) attachVoterHandler(voterID VoterID, event *events.Event, handler interface{}) {
+	closure := events.NewClosure(handler)
+
+	n.votersMutex.Lock()
+	if _, exists := n.voterClosures[voterID]; !exists {
+		n.voterClosures[voterID] = make(map[*events.Event]*events.Closure)
+	}
+	n.voterClosures[voterID][event] = closure
+	n.votersMutex.Unlock()
+
+	event.Attach(closure)
+}
+
+// RemoveVoter removes the Voter with the given identifier from the Network and detaches all of its handlers. The
+// remaining Voters keep counting its last statement until the given statementExpiry has passed (its weight is removed
+// from the WeightDistribution afterwards).
+func (n *Network) RemoveVoter(voterID VoterID, statementExpiry time.Duration) {
+	n.votersMutex.Lock()
+	defer n.votersMutex.Unlock()
+
+	if _, exists := n.voters[voterID]; !exists {
+		return
+	}
+
+	for event, closure := range n.voterClosures[voterID] {
+		event.Detach(closure)
+	}
+	delete(n.voterClosures, voterID)
+	delete(n.voters, voterID)
+
+	n.schedule(statementExpiry, func() {
+		for _, voter := range n.Voters() {
+			voter.ApprovalWeightManager().ExpireStatement(voterID)
+		}
+
+		n.WeightDistribution.RemoveWeight(voterID)
+	})
+}
+
+// Voter returns the Voter with the given identifier.
+func (n *Network) Voter(voterID VoterID) (voter Voter, exists bool) {
+	n.votersMutex.RLock()
+	defer n.votersMutex.RUnlock()
+
+	voter, exists = n.voters[voterID]
+
+	return voter, exists
+}
+
+// perceivesNoise returns true if the PerceptionNoise applies to the Voter with the given identifier (Attackers perceive
+// the exact state of the Network).
+func (n *Network) perceivesNoise(voterID VoterID) bool {
+	voter, exists := n.Voter(voterID)
+	if !exists {
+		return true
+	}
+
+	_, isAttacker := voter.(Attacker)
+
+	return !isAttacker
+}
+
+// Voters returns a list of all Voters that are currently part of the Network (ordered by their identifier).
+func (n *Network) Voters() (voters []Voter) {
+	n.votersMutex.RLock()
+	defer n.votersMutex.RUnlock()
+
+	voters = make([]Voter, 0, len(n.voters))
+	for _, voter := range n.voters {
+		voters = append(voters, voter)
+	}
+
+	sort.Slice(voters, func(i, j int) bool {
+		return voters[i].ID() < voters[j].ID()
+	})
+
+	return voters
+}
+
+// ResolveConflicts validates the parameters and the WeightDistribution, introduces the given Branches and starts the
+// voting.
+func (n *Network) ResolveConflicts(branchIDs ...BranchID) (err error) {
+	if err = n.start(branchIDs...); err != nil {
+		return err
+	}
+
+	go func() {
+		for n.voteRound() {
+		}
+	}()
+
+	return nil
+}
+
+// Simulate validates the parameters and the WeightDistribution, introduces the given Branches and runs the voting in
+// the calling goroutine until the conflict is resolved or the given duration passed on the Clock of the Network. It
+// returns true and the time it took if the conflict was resolved.
+func (n *Network) Simulate(duration time.Duration, branchIDs ...BranchID) (resolved bool, resolutionTime time.Duration, err error) {
+	startTime := n.Clock.Now()
+	if err = n.start(branchIDs...); err != nil {
+		return false, 0, err
+	}
+
+	for n.Clock.Now().Sub(startTime) < duration {
+		if n.ConflictResolved() {
+			return true, n.Clock.Now().Sub(startTime), nil
+		}
+
+		if !n.voteRound() {
+			break
+		}
 	}
+
+	return false, 0, nil
 }
 
-func (n *Network) ResolveConflicts(branchIDs ...BranchID) {
+// start validates the parameters and the WeightDistribution, introduces the given Branches and marks the Network as
+// running.
+func (n *Network) start(branchIDs ...BranchID) (err error) {
+	if err = n.ValidateParameters(); err != nil {
+		return err
+	}
+
+	if err = n.ValidateWeightDistribution(); err != nil {
+		return err
+	}
+
 	for _, branchID := range branchIDs {
 		n.VoteReceived.Trigger(&Vote{
 			Issuer:   NewVoterID(),
@@ -58,36 +299,204 @@ func (n *Network) ResolveConflicts(branchIDs ...BranchID) {
 		})
 	}
 
-	go func() {
-		votersList := make([]Voter, 0)
-		for _, voter := range n.Voters {
-			votersList = append(votersList, voter)
+	n.votersMutex.Lock()
+	n.running = true
+	n.votersMutex.Unlock()
+
+	return nil
+}
+
+// voteRound lets every online Voter vote once. It returns false if the Network was shut down.
+func (n *Network) voteRound() (running bool) {
+	votersList := n.Voters()
+	if len(votersList) == 0 {
+		n.Clock.Sleep(voteInterval)
+	}
+
+	voted := false
+	for _, voter := range votersList {
+		opinionChanged, running := n.vote(voter)
+		if !running {
+			return false
 		}
 
-		for {
-			for _, voter := range votersList {
-				n.BeforeNextVote.Trigger(voter)
+		if opinionChanged {
+			voted = true
+			n.Clock.Sleep(voteInterval)
+		}
+	}
 
-				if voter.SendVote() {
-					time.Sleep(100 * time.Millisecond)
-				}
+	if !voted {
+		n.Clock.Idle(voteInterval)
+	}
+
+	return true
+}
+
+// vote lets the given Voter vote if it is online. It returns false if the Network was shut down.
+func (n *Network) vote(voter Voter) (opinionChanged, running bool) {
+	n.inFlightMutex.RLock()
+	defer n.inFlightMutex.RUnlock()
+
+	if n.isShutdown() {
+		return false, false
+	}
+
+	if !voter.Online() {
+		return false, true
+	}
+
+	voter.ApprovalWeightManager().PruneExpiredStatements()
+
+	n.BeforeNextVote.Trigger(voter)
+
+	return voter.SendVote(), true
+}
+
+// schedule executes the given callback after the given delay on the Clock of the Network (a SimulatedClock executes it
+// as part of the voting). Callbacks that become due after the Network was shut down are dropped.
+func (n *Network) schedule(delay time.Duration, callback func()) {
+	n.Clock.AfterFunc(delay, func() {
+		n.inFlightMutex.RLock()
+		defer n.inFlightMutex.RUnlock()
+
+		if n.isShutdown() {
+			return
+		}
+
+		callback()
+	})
+}
+
+// isShutdown returns true if the Network was shut down.
+func (n *Network) isShutdown() bool {
+	select {
+	case <-n.shutdown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown stops the voting of a running Network. It waits for the votes and scheduled callbacks that are in flight,
+// so no Voter votes after it returned.
+func (n *Network) Shutdown() {
+	n.shutdownOnce.Do(func() {
+		close(n.shutdown)
+
+		// wait for the votes and callbacks that are in flight
+		n.inFlightMutex.Lock()
+		n.inFlightMutex.Unlock()
+	})
+}
+
+// bootstrapPeer returns the Voter that newly joining Voters copy their initial state from (nil if the Network is not
+// running yet).
+func (n *Network) bootstrapPeer() (peer Voter) {
+	if !n.running {
+		return nil
+	}
+
+	for _, voter := range n.voters {
+		if peer == nil || voter.ID() < peer.ID() {
+			peer = voter
+		}
+	}
+
+	return peer
+}
+
+// ValidateParameters checks that the configuration parameters of the Network are within their valid ranges.
+func (n *Network) ValidateParameters() error {
+	if n.MetastabilityBreakingThreshold < 0 {
+		return fmt.Errorf("MetastabilityBreakingThreshold of %s must not be negative: %w", n.MetastabilityBreakingThreshold, ErrInvalidParameter)
+	}
+
+	if math.IsNaN(n.ConfirmationThreshold) || n.ConfirmationThreshold <= 0.5 || n.ConfirmationThreshold > 1 {
+		return fmt.Errorf("ConfirmationThreshold of %f must be in (0.5, 1]: %w", n.ConfirmationThreshold, ErrInvalidParameter)
+	}
+
+	if math.IsNaN(n.BreakerCeiling) || n.BreakerCeiling < 0 || n.BreakerCeiling > 1 {
+		return fmt.Errorf("BreakerCeiling of %f must be in [0, 1]: %w", n.BreakerCeiling, ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// ValidateWeightDistribution checks that every Voter has a valid weight and that the weights sum up to 1.0.
+func (n *Network) ValidateWeightDistribution() error {
+	voters := n.Voters()
+	if len(voters) == 0 {
+		return fmt.Errorf("network does not contain any voters: %w", ErrInvalidWeightDistribution)
+	}
+
+	var totalWeight float64
+	for _, voter := range voters {
+		weight := n.WeightDistribution.Weight(voter.ID())
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("%s has a weight of %f: %w", voter.ID(), weight, ErrInvalidWeightDistribution)
+		}
+
+		totalWeight += weight
+	}
+
+	if math.Abs(totalWeight-1) > weightTolerance {
+		return fmt.Errorf("total weight of %f does not sum up to 1.0: %w", totalWeight, ErrInvalidWeightDistribution)
+	}
+
+	return nil
+}
+
+// WeightDistributionStats returns statistics about how the weight is distributed between the honest Voters and the
+// attackers (all Voters that implement the Attacker interface).
+func (n *Network) WeightDistributionStats() (stats *WeightDistributionStats) {
+	stats = &WeightDistributionStats{}
+	for _, voter := range n.Voters() {
+		voterID := voter.ID()
+		weight := n.WeightDistribution.Weight(voterID)
+		stats.TotalWeight += weight
+
+		if _, isAttacker := voter.(Attacker); !isAttacker {
+			stats.HonestVoters++
+			stats.HonestWeight += weight
+
+			if weight > stats.LargestHonestVoterWeight || stats.LargestHonestVoter == 0 {
+				stats.LargestHonestVoter = voterID
+				stats.LargestHonestVoterWeight = weight
 			}
+
+			continue
 		}
-	}()
+
+		stats.Attackers++
+		stats.AttackerWeight += weight
+
+		if weight > stats.LargestAttackerWeight || stats.LargestAttacker == 0 {
+			stats.LargestAttacker = voterID
+			stats.LargestAttackerWeight = weight
+		}
+	}
+
+	if stats.TotalWeight != 0 {
+		stats.AttackerShare = stats.AttackerWeight / stats.TotalWeight
+	}
+	stats.AttackerIsHeaviestVoter = stats.LargestAttacker != 0 && stats.LargestAttackerWeight > stats.LargestHonestVoterWeight
+
+	return stats
 }
 
 func (n *Network) ApprovalWeightByVoterType() (approvalWeightByVoterType map[string]map[BranchID]float64) {
 	approvalWeightByVoterType = make(map[string]map[BranchID]float64)
 
 	branchesWithKnownVoters := set.New()
-	for _, voter := range n.Voters {
+	for _, voter := range n.Voters() {
 		honestVoter, ok := voter.(*HonestVoter)
-		if !ok {
+		if !ok || !honestVoter.Online() {
 			continue
 		}
 
 		for voterID, branchID := range honestVoter.approvalWeightManager.LastStatements() {
-			voter, voterExists := n.Voters[voterID]
+			voter, voterExists := n.Voter(voterID)
 			voterType := "<None>"
 			var voterWeight float64
 			if voterExists {
@@ -118,18 +527,96 @@ func (n *Network) ApprovalWeightByVoterType() (approvalWeightByVoterType map[str
 	return approvalWeightByVoterType
 }
 
+// SolidificationTimeSpread returns the time between the first and the last HonestVoter seeing the given Branch. It
+// measures how much the time scaling of the metastability breaker diverges between the honest Voters.
+func (n *Network) SolidificationTimeSpread(branchID BranchID) time.Duration {
+	var earliest, latest time.Time
+	for _, voter := range n.Voters() {
+		if voter.Type() != "HonestVoter" {
+			continue
+		}
+
+		metadata := voter.BranchManager().Metadata(branchID)
+		if metadata == nil {
+			continue
+		}
+
+		if earliest.IsZero() || metadata.SolidificationTime.Before(earliest) {
+			earliest = metadata.SolidificationTime
+		}
+		if metadata.SolidificationTime.After(latest) {
+			latest = metadata.SolidificationTime
+		}
+	}
+
+	return latest.Sub(earliest)
+}
+
+// ConfirmedBranch returns the Branch whose approval weight reached the ConfirmationThreshold in the perception of the
+// first online HonestVoter.
+func (n *Network) ConfirmedBranch() (confirmedBranch BranchID, confirmed bool) {
+	for _, voter := range n.Voters() {
+		if voter.Type() != "HonestVoter" || !voter.Online() {
+			continue
+		}
+
+		for branchID := range voter.BranchManager().BranchIDs() {
+			if voter.ApprovalWeightManager().Weight(branchID) >= n.ConfirmationThreshold-weightTolerance {
+				return branchID, true
+			}
+		}
+
+		return UndefinedBranchID, false
+	}
+
+	return UndefinedBranchID, false
+}
+
+// AwaitConflictResolution blocks until the conflict is resolved or the given timeout passed and returns true if the
+// conflict was resolved.
+func (n *Network) AwaitConflictResolution(timeout time.Duration) (resolved bool) {
+	deadline := time.Now().Add(timeout)
+	for !n.ConflictResolved() {
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return true
+}
+
+// ConflictResolved returns true if all online HonestVoters agree on the same Branch. Offline HonestVoters are ignored
+// as their statements are not expected to change anymore.
 func (n *Network) ConflictResolved() bool {
+	var perspective *HonestVoter
 	expectedWeight := float64(0)
-	for _, voter := range n.Voters {
-		if voter.Type() != "HonestVoter" {
+	for _, voter := range n.Voters() {
+		if voter.Type() != "HonestVoter" || !voter.Online() {
 			continue
 		}
 
+		if honestVoter, ok := voter.(*HonestVoter); ok && perspective == nil {
+			perspective = honestVoter
+		}
+
 		expectedWeight += n.WeightDistribution.Weight(voter.ID())
 	}
 
-	for _, weight := range n.ApprovalWeightByVoterType()["HonestVoter"] {
-		if weight == expectedWeight {
+	if perspective == nil {
+		return false
+	}
+
+	weightByBranch := make(map[BranchID]float64)
+	for voterID, branchID := range perspective.ApprovalWeightManager().LastStatements() {
+		if voter, exists := n.Voter(voterID); exists && voter.Type() == "HonestVoter" && voter.Online() {
+			weightByBranch[branchID] += n.WeightDistribution.Weight(voterID)
+		}
+	}
+
+	for _, weight := range weightByBranch {
+		if math.Abs(weight-expectedWeight) < weightTolerance {
 			return true
 		}
 	}
@@ -161,6 +648,41 @@ func (n *Network) String() string {
 
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// region WeightDistributionStats //////////////////////////////////////////////////////////////////////////////////////
+
+// WeightDistributionStats contains statistics about the distribution of weight between honest Voters and attackers.
+type WeightDistributionStats struct {
+	HonestVoters             int
+	Attackers                int
+	TotalWeight              float64
+	HonestWeight             float64
+	AttackerWeight           float64
+	AttackerShare            float64
+	LargestHonestVoter       VoterID
+	LargestHonestVoterWeight float64
+	LargestAttacker          VoterID
+	LargestAttackerWeight    float64
+	AttackerIsHeaviestVoter  bool
+}
+
+func (w *WeightDistributionStats) String() string {
+	return stringify.Struct("WeightDistributionStats",
+		stringify.StructField("HonestVoters", w.HonestVoters),
+		stringify.StructField("Attackers", w.Attackers),
+		stringify.StructField("TotalWeight", fmt.Sprintf("%0.2f", w.TotalWeight)),
+		stringify.StructField("HonestWeight", fmt.Sprintf("%0.2f", w.HonestWeight)),
+		stringify.StructField("AttackerWeight", fmt.Sprintf("%0.2f", w.AttackerWeight)),
+		stringify.StructField("AttackerShare", fmt.Sprintf("%0.2f", w.AttackerShare)),
+		stringify.StructField("LargestHonestVoter", w.LargestHonestVoter),
+		stringify.StructField("LargestHonestVoterWeight", fmt.Sprintf("%0.2f", w.LargestHonestVoterWeight)),
+		stringify.StructField("LargestAttacker", w.LargestAttacker),
+		stringify.StructField("LargestAttackerWeight", fmt.Sprintf("%0.2f", w.LargestAttackerWeight)),
+		stringify.StructField("AttackerIsHeaviestVoter", w.AttackerIsHeaviestVoter),
+	)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 // region BranchManager ////////////////////////////////////////////////////////////////////////////////////////////////
 
 type BranchManager struct {
@@ -178,6 +700,9 @@ func NewBranchManager(voter Voter) *BranchManager {
 }
 
 func (b *BranchManager) BranchIDs() (branchIDs BranchIDs) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
 	branchIDs = make(BranchIDs)
 	for branchID := range b.metadataByID {
 		branchIDs[branchID] = types.Void
@@ -192,7 +717,7 @@ func (b *BranchManager) RegisterBranch(branchID BranchID) {
 
 	if _, exists := b.metadataByID[branchID]; !exists {
 		b.metadataByID[branchID] = &BranchMetadata{
-			SolidificationTime: time.Now(),
+			SolidificationTime: b.voter.Network().Clock.Now(),
 		}
 	}
 }
@@ -204,6 +729,37 @@ func (b *BranchManager) Metadata(branchID BranchID) *BranchMetadata {
 	return b.metadataByID[branchID]
 }
 
+// Snapshot returns an immutable copy of the Branches that are currently known to the BranchManager.
+func (b *BranchManager) Snapshot() *BranchSnapshot {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	metadataByID := make(map[BranchID]BranchMetadata, len(b.metadataByID))
+	for branchID, metadata := range b.metadataByID {
+		metadataByID[branchID] = *metadata
+	}
+
+	return &BranchSnapshot{
+		metadataByID: metadataByID,
+	}
+}
+
+// Bootstrap copies the metadata of all Branches that are known to the given peer but unknown to this BranchManager.
+func (b *BranchManager) Bootstrap(peer *BranchManager) {
+	peer.mutex.RLock()
+	defer peer.mutex.RUnlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for branchID, metadata := range peer.metadataByID {
+		if _, exists := b.metadataByID[branchID]; !exists {
+			metadataCopy := *metadata
+			b.metadataByID[branchID] = &metadataCopy
+		}
+	}
+}
+
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // region BranchID /////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -220,8 +776,31 @@ func (b BranchID) String() string {
 	return "BranchID(" + fmt.Sprintf("%d", b) + ")"
 }
 
+// lowerHash returns a BranchID with a lower hash than the BranchID. It skips the UndefinedBranchID, so the result is
+// always a valid Branch.
+func (b BranchID) lowerHash() (lowerBranchID BranchID) {
+	if lowerBranchID = b - 1; lowerBranchID == UndefinedBranchID {
+		lowerBranchID--
+	}
+
+	return lowerBranchID
+}
+
 type BranchIDs map[BranchID]types.Empty
 
+// Sorted returns the BranchIDs in ascending order, so they can be iterated deterministically.
+func (b BranchIDs) Sorted() (sortedBranchIDs []BranchID) {
+	sortedBranchIDs = make([]BranchID, 0, len(b))
+	for branchID := range b {
+		sortedBranchIDs = append(sortedBranchIDs, branchID)
+	}
+	sort.Slice(sortedBranchIDs, func(i, j int) bool {
+		return sortedBranchIDs[i] < sortedBranchIDs[j]
+	})
+
+	return sortedBranchIDs
+}
+
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // region BranchMetadata ///////////////////////////////////////////////////////////////////////////////////////////////
@@ -241,6 +820,7 @@ type ApprovalWeightManager struct {
 	weights             map[BranchID]float64
 	weightsMutex        sync.RWMutex
 	lastStatements      map[VoterID]BranchID
+	lastStatementTimes  map[VoterID]time.Time
 	lastStatementsMutex sync.RWMutex
 }
 
@@ -250,9 +830,10 @@ func NewApprovalWeightManager(voter Voter) *ApprovalWeightManager {
 			handler.(func(*Vote))(params[0].(*Vote))
 		}),
 
-		voter:          voter,
-		weights:        make(map[BranchID]float64),
-		lastStatements: make(map[VoterID]BranchID),
+		voter:              voter,
+		weights:            make(map[BranchID]float64),
+		lastStatements:     make(map[VoterID]BranchID),
+		lastStatementTimes: make(map[VoterID]time.Time),
 	}
 }
 
@@ -262,6 +843,8 @@ func (a *ApprovalWeightManager) ProcessVote(vote *Vote) {
 
 	a.voter.BranchManager().RegisterBranch(vote.BranchID)
 
+	a.lastStatementTimes[vote.Issuer] = a.voter.Network().Clock.Now()
+
 	lastBranchID, statementExists := a.lastStatements[vote.Issuer]
 	if statementExists {
 		if vote.BranchID == lastBranchID {
@@ -277,6 +860,85 @@ func (a *ApprovalWeightManager) ProcessVote(vote *Vote) {
 	a.VoteProcessed.Trigger(vote)
 }
 
+// Bootstrap copies the statements of all issuers that are known to the given peer but unknown to this
+// ApprovalWeightManager.
+func (a *ApprovalWeightManager) Bootstrap(peer *ApprovalWeightManager) {
+	peerStatements := peer.LastStatements()
+
+	a.lastStatementsMutex.Lock()
+	defer a.lastStatementsMutex.Unlock()
+
+	for issuer, branchID := range peerStatements {
+		if _, exists := a.lastStatements[issuer]; exists {
+			continue
+		}
+
+		a.voter.BranchManager().RegisterBranch(branchID)
+		a.updateWeight(branchID, a.voter.Network().WeightDistribution.Weight(issuer))
+		a.lastStatements[issuer] = branchID
+		a.lastStatementTimes[issuer] = a.voter.Network().Clock.Now()
+	}
+}
+
+// Snapshot returns an immutable copy of the current statements and weights of the ApprovalWeightManager.
+func (a *ApprovalWeightManager) Snapshot() (snapshot *ApprovalWeightSnapshot) {
+	a.lastStatementsMutex.RLock()
+	defer a.lastStatementsMutex.RUnlock()
+	a.weightsMutex.RLock()
+	defer a.weightsMutex.RUnlock()
+
+	snapshot = &ApprovalWeightSnapshot{
+		voterWeights:   a.voter.Network().WeightDistribution.Weights(),
+		weights:        make(map[BranchID]float64, len(a.weights)),
+		lastStatements: make(map[VoterID]BranchID, len(a.lastStatements)),
+	}
+	for branchID, weight := range a.weights {
+		snapshot.weights[branchID] = weight
+	}
+	for issuer, branchID := range a.lastStatements {
+		snapshot.lastStatements[issuer] = branchID
+	}
+
+	return snapshot
+}
+
+// ExpireStatement removes the last statement of the given issuer and the weight that it contributed.
+func (a *ApprovalWeightManager) ExpireStatement(issuer VoterID) {
+	a.lastStatementsMutex.Lock()
+	defer a.lastStatementsMutex.Unlock()
+
+	branchID, exists := a.lastStatements[issuer]
+	if !exists {
+		return
+	}
+
+	a.updateWeight(branchID, -a.voter.Network().WeightDistribution.Weight(issuer))
+	delete(a.lastStatements, issuer)
+	delete(a.lastStatementTimes, issuer)
+}
+
+// PruneExpiredStatements removes the statements that were not refreshed within the StatementTTL of the Network.
+func (a *ApprovalWeightManager) PruneExpiredStatements() {
+	statementTTL := a.voter.Network().StatementTTL
+	if statementTTL == 0 {
+		return
+	}
+
+	a.lastStatementsMutex.Lock()
+	defer a.lastStatementsMutex.Unlock()
+
+	now := a.voter.Network().Clock.Now()
+	for issuer, statementTime := range a.lastStatementTimes {
+		if now.Sub(statementTime) < statementTTL {
+			continue
+		}
+
+		a.updateWeight(a.lastStatements[issuer], -a.voter.Network().WeightDistribution.Weight(issuer))
+		delete(a.lastStatements, issuer)
+		delete(a.lastStatementTimes, issuer)
+	}
+}
+
 func (a *ApprovalWeightManager) Weight(branchID BranchID) float64 {
 	a.weightsMutex.RLock()
 	defer a.weightsMutex.RUnlock()
@@ -296,6 +958,16 @@ func (a *ApprovalWeightManager) LastStatements() (lastStatements map[VoterID]Bra
 	return lastStatements
 }
 
+// LastStatement returns the Branch that the given issuer voted for most recently.
+func (a *ApprovalWeightManager) LastStatement(issuer VoterID) (branchID BranchID, exists bool) {
+	a.lastStatementsMutex.RLock()
+	defer a.lastStatementsMutex.RUnlock()
+
+	branchID, exists = a.lastStatements[issuer]
+
+	return branchID, exists
+}
+
 func (a *ApprovalWeightManager) updateWeight(branchID BranchID, diff float64) {
 	a.weightsMutex.Lock()
 	defer a.weightsMutex.Unlock()
@@ -344,7 +1016,7 @@ func (a *ApprovalWeightManager) String() string {
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
 
 	for voterID, branchID := range a.lastStatements {
-		voter, exists := a.voter.Network().Voters[voterID]
+		voter, exists := a.voter.Network().Voter(voterID)
 		if !exists {
 			continue
 		}
@@ -364,7 +1036,8 @@ func (a *ApprovalWeightManager) String() string {
 // region WeightDistribution ///////////////////////////////////////////////////////////////////////////////////////////
 
 type WeightDistribution struct {
-	weights map[VoterID]float64
+	weights      map[VoterID]float64
+	weightsMutex sync.RWMutex
 }
 
 func NewWeightDistribution() *WeightDistribution {
@@ -374,14 +1047,71 @@ func NewWeightDistribution() *WeightDistribution {
 }
 
 func (w *WeightDistribution) SetWeight(voterID VoterID, weight float64) {
+	w.weightsMutex.Lock()
+	defer w.weightsMutex.Unlock()
+
 	w.weights[voterID] = weight
 }
 
 func (w *WeightDistribution) Weight(voterID VoterID) float64 {
+	w.weightsMutex.RLock()
+	defer w.weightsMutex.RUnlock()
+
 	return w.weights[voterID]
 }
 
+// Weights returns a copy of the weights of all Voters.
+func (w *WeightDistribution) Weights() (weights map[VoterID]float64) {
+	w.weightsMutex.RLock()
+	defer w.weightsMutex.RUnlock()
+
+	weights = make(map[VoterID]float64, len(w.weights))
+	for voterID, weight := range w.weights {
+		weights[voterID] = weight
+	}
+
+	return weights
+}
+
+// RemoveWeight removes the weight of the Voter with the given identifier.
+func (w *WeightDistribution) RemoveWeight(voterID VoterID) {
+	w.weightsMutex.Lock()
+	defer w.weightsMutex.Unlock()
+
+	delete(w.weights, voterID)
+}
+
+// TotalWeight returns the sum of the weights of all Voters.
+func (w *WeightDistribution) TotalWeight() (totalWeight float64) {
+	w.weightsMutex.RLock()
+	defer w.weightsMutex.RUnlock()
+
+	for _, weight := range w.weights {
+		totalWeight += weight
+	}
+
+	return totalWeight
+}
+
+// Normalize scales the weights of all Voters so that they sum up to 1.0 while keeping their relative proportions.
+func (w *WeightDistribution) Normalize() {
+	totalWeight := w.TotalWeight()
+	if totalWeight == 0 {
+		return
+	}
+
+	w.weightsMutex.Lock()
+	defer w.weightsMutex.Unlock()
+
+	for voterID, weight := range w.weights {
+		w.weights[voterID] = weight / totalWeight
+	}
+}
+
 func (w *WeightDistribution) String() string {
+	w.weightsMutex.RLock()
+	defer w.weightsMutex.RUnlock()
+
 	weightDistribution := stringify.StructBuilder("WeightDistribution")
 	for voterID, weight := range w.weights {
 		weightDistribution.AddField(stringify.StructField(voterID.String(), fmt.Sprintf("%0.2f", weight)))
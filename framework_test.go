@@ -0,0 +1,230 @@
+package metastabilitybreaker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/hive.go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork_ValidateWeightDistribution(t *testing.T) {
+	network := NewNetwork(0)
+	assert.ErrorIs(t, network.ValidateWeightDistribution(), ErrInvalidWeightDistribution)
+
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewLowerHashVoter, FixedWeight(0.08))
+	assert.ErrorIs(t, network.ValidateWeightDistribution(), ErrInvalidWeightDistribution)
+	assert.ErrorIs(t, network.ResolveConflicts(NewBranchID(1000)), ErrInvalidWeightDistribution)
+
+	network.WeightDistribution.Normalize()
+	assert.NoError(t, network.ValidateWeightDistribution())
+
+	network = NewNetwork(0)
+	network.AddVoters(1, NewHonestVoter, FixedWeight(1.2))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(-0.2))
+	assert.ErrorIs(t, network.ValidateWeightDistribution(), ErrInvalidWeightDistribution)
+}
+
+func TestNetwork_ValidateParameters(t *testing.T) {
+	network := NewNetwork(0)
+	assert.NoError(t, network.ValidateParameters())
+
+	network.ConfirmationThreshold = 0.66
+	network.BreakerCeiling = 0.5
+	assert.NoError(t, network.ValidateParameters())
+
+	network.ConfirmationThreshold = 0.5
+	assert.ErrorIs(t, network.ValidateParameters(), ErrInvalidParameter)
+
+	network.ConfirmationThreshold = 0.66
+	network.BreakerCeiling = 1.1
+	assert.ErrorIs(t, network.ValidateParameters(), ErrInvalidParameter)
+
+	network.BreakerCeiling = 0.5
+	network.MetastabilityBreakingThreshold = -1
+	assert.ErrorIs(t, network.ValidateParameters(), ErrInvalidParameter)
+
+	network.AddVoters(1, NewHonestVoter, FixedWeight(1))
+	assert.ErrorIs(t, network.ResolveConflicts(NewBranchID(1)), ErrInvalidParameter)
+}
+
+func TestNetwork_WeightDistributionStats(t *testing.T) {
+	network := NewNetwork(0)
+	network.AddVoters(4, NewHonestVoter, SequentialWeights([]float64{0.1, 0.3, 0.2, 0.1}))
+	network.AddVoters(2, NewMinorityVoter, SequentialWeights([]float64{0.2, 0.1}))
+
+	stats := network.WeightDistributionStats()
+	assert.InDelta(t, 1, stats.TotalWeight, 1e-9)
+	assert.InDelta(t, 0.7, stats.HonestWeight, 1e-9)
+	assert.InDelta(t, 0.3, stats.AttackerWeight, 1e-9)
+	assert.InDelta(t, 0.3, stats.AttackerShare, 1e-9)
+	assert.Equal(t, 4, stats.HonestVoters)
+	assert.Equal(t, 2, stats.Attackers)
+	assert.Equal(t, 0.3, stats.LargestHonestVoterWeight)
+	assert.Equal(t, 0.2, stats.LargestAttackerWeight)
+	largestAttacker, exists := network.Voter(stats.LargestAttacker)
+	assert.True(t, exists)
+	assert.Equal(t, "MinorityVoter", largestAttacker.Type())
+	assert.False(t, stats.AttackerIsHeaviestVoter)
+
+	network.AddVoters(1, NewLowerHashVoter, FixedWeight(0.4))
+	assert.True(t, network.WeightDistributionStats().AttackerIsHeaviestVoter)
+
+	network = NewNetwork(0)
+	network.AddVoters(1, NewHonestVoter, FixedWeight(0.4))
+	network.AddVoters(1, NewRandomVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewStubbornVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewNoisyHonestVoter(0.1), FixedWeight(0.1))
+	network.AddVoters(1, NewCrashFaultyVoter(time.Second), FixedWeight(0.1))
+	network.AddVoters(1, NewWithholdingVoter(0.9), FixedWeight(0.2))
+
+	stats = network.WeightDistributionStats()
+	assert.InDelta(t, 0.8, stats.HonestWeight, 1e-9)
+	assert.InDelta(t, 0.2, stats.AttackerWeight, 1e-9)
+	assert.Equal(t, 5, stats.HonestVoters)
+	assert.Equal(t, 1, stats.Attackers)
+	assert.False(t, stats.AttackerIsHeaviestVoter)
+}
+
+func TestNetwork_Churn(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	defer network.Shutdown()
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+	require.NoError(t, network.ResolveConflicts(NewBranchID(1), NewBranchID(2)))
+
+	time.Sleep(300 * time.Millisecond)
+
+	leaver := network.Voters()[0]
+	network.RemoveVoter(leaver.ID(), 200*time.Millisecond)
+	_, leaverExists := network.Voter(leaver.ID())
+	assert.False(t, leaverExists)
+
+	network.AddVoters(2, NewHonestVoter, FixedWeight(0.05))
+	joiner := network.Voters()[len(network.Voters())-1]
+	assert.NotEmpty(t, joiner.BranchManager().BranchIDs())
+	assert.NotEmpty(t, joiner.ApprovalWeightManager().LastStatements())
+
+	assert.Eventually(t, func() bool {
+		for _, voter := range network.Voters() {
+			if _, exists := voter.ApprovalWeightManager().LastStatements()[leaver.ID()]; exists {
+				return false
+			}
+		}
+
+		return true
+	}, 2*time.Second, 50*time.Millisecond, "statements of the leaving voter should expire")
+
+	assert.Eventually(t, network.ConflictResolved, 10*time.Second, 50*time.Millisecond, "failed to resolve metastable state")
+}
+
+func TestNetwork_RemoveVoter(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.Clock = NewSimulatedClock(time.Now())
+	network.AddVoters(4, NewHonestVoter, FixedWeight(0.2))
+	network.AddVoters(2, NewSlowMinorityVoter, FixedWeight(0.01))
+	voters := network.Voters()
+	leaver, attacker := voters[4], voters[5]
+
+	network.VoteReceived.Trigger(&Vote{Issuer: voters[0].ID(), BranchID: NewBranchID(1)})
+	network.VoteReceived.Trigger(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(2)})
+
+	network.RemoveVoter(leaver.ID(), 200*time.Millisecond)
+	assert.Empty(t, network.voterClosures[leaver.ID()])
+	assert.Equal(t, 0.01, network.WeightDistribution.Weight(leaver.ID()), "weight should count until the statement expired")
+
+	votesByIssuer := make(map[VoterID]int)
+	network.VoteReceived.Attach(events.NewClosure(func(vote *Vote) {
+		votesByIssuer[vote.Issuer]++
+	}))
+	network.BeforeNextVote.Trigger(voters[0])
+	assert.Equal(t, 1, votesByIssuer[attacker.ID()], "the remaining attacker should react to the upcoming vote")
+	assert.Zero(t, votesByIssuer[leaver.ID()], "the handlers of the leaving attacker should be detached")
+
+	network.Clock.Sleep(200 * time.Millisecond)
+	assert.InDelta(t, 0.81, network.WeightDistribution.TotalWeight(), 1e-9)
+}
+
+func TestNetwork_AttackersVoteInSimulatedTime(t *testing.T) {
+	network := NewNetwork(5 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(1)
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+	minorityVoter := network.Voters()[8]
+
+	var votesOfMinorityVoter int
+	network.VoteReceived.Attach(events.NewClosure(func(vote *Vote) {
+		if vote.Issuer == minorityVoter.ID() {
+			votesOfMinorityVoter++
+		}
+	}))
+
+	_, _, err := network.Simulate(time.Second, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+	network.Shutdown()
+
+	assert.Greater(t, votesOfMinorityVoter, 0, "the attacker should vote within the simulated vote loop")
+}
+
+func TestNetwork_Shutdown(t *testing.T) {
+	network := NewNetwork(5 * time.Second)
+	network.PerceptionNoise.MaxUpdateDelay = 50 * time.Millisecond
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+
+	var sentVotes int64
+	network.VoteReceived.Attach(events.NewClosure(func(vote *Vote) {
+		atomic.AddInt64(&sentVotes, 1)
+	}))
+	require.NoError(t, network.ResolveConflicts(NewBranchID(1), NewBranchID(2)))
+
+	time.Sleep(300 * time.Millisecond)
+	network.Shutdown()
+	sentVotesAtShutdown := atomic.LoadInt64(&sentVotes)
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, sentVotesAtShutdown, atomic.LoadInt64(&sentVotes), "no vote should be sent after the shutdown")
+}
+
+func TestApprovalWeightManager_StatementTTL(t *testing.T) {
+	network := NewNetwork(0)
+	network.StatementTTL = 100 * time.Millisecond
+	network.AddVoters(2, NewHonestVoter, FixedWeight(0.5))
+	voters := network.Voters()
+
+	approvalWeightManager := voters[0].ApprovalWeightManager()
+	approvalWeightManager.ProcessVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(1)})
+	approvalWeightManager.PruneExpiredStatements()
+	assert.Equal(t, 0.5, approvalWeightManager.Weight(NewBranchID(1)))
+
+	time.Sleep(60 * time.Millisecond)
+	approvalWeightManager.ProcessVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(1)})
+	time.Sleep(60 * time.Millisecond)
+	approvalWeightManager.PruneExpiredStatements()
+	assert.Equal(t, 0.5, approvalWeightManager.Weight(NewBranchID(1)), "refreshed statement should not expire")
+
+	time.Sleep(60 * time.Millisecond)
+	approvalWeightManager.PruneExpiredStatements()
+	assert.Equal(t, float64(0), approvalWeightManager.Weight(NewBranchID(1)))
+	_, exists := approvalWeightManager.LastStatement(voters[1].ID())
+	assert.False(t, exists)
+}
+
+func TestHonestVoter_Heartbeat(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	defer network.Shutdown()
+	network.StatementTTL = 500 * time.Millisecond
+	network.HeartbeatInterval = 200 * time.Millisecond
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+	require.NoError(t, network.ResolveConflicts(NewBranchID(1), NewBranchID(2)))
+
+	require.Eventually(t, network.ConflictResolved, 10*time.Second, 50*time.Millisecond, "failed to resolve metastable state")
+
+	time.Sleep(2 * network.StatementTTL)
+	assert.True(t, network.ConflictResolved(), "heartbeats should keep the honest statements alive")
+}
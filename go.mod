@@ -6,4 +6,5 @@ require (
 	github.com/iotaledger/hive.go v0.0.0-20210821074123-831d7702ae07
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,237 @@
+package metastabilitybreaker
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/types"
+)
+
+// region MetricsExporter //////////////////////////////////////////////////////////////////////////////////////////////
+
+// MetricsExporter exposes the state of a Network in the Prometheus text exposition format. It counts the votes and the
+// opinion flips from the moment it is created and reads the gauges (branch weights, weight gap, breaker threshold,
+// resolution status) from the Network on every scrape. It is an http.Handler, so it can be mounted on the /metrics path
+// of any http.ServeMux.
+type MetricsExporter struct {
+	network            *Network
+	opinionFlipCounter *opinionFlipCounter
+	closures           map[*events.Event]*events.Closure
+	votesSent          int
+	votesProcessed     map[string]int
+	mutex              sync.Mutex
+}
+
+// NewMetricsExporter returns a MetricsExporter for the given Network.
+func NewMetricsExporter(network *Network) (metricsExporter *MetricsExporter) {
+	metricsExporter = &MetricsExporter{
+		network:            network,
+		opinionFlipCounter: newOpinionFlipCounter(network),
+		closures:           make(map[*events.Event]*events.Closure),
+		votesProcessed:     make(map[string]int),
+	}
+
+	metricsExporter.closures[network.VoteReceived] = events.NewClosure(metricsExporter.voteSent)
+	metricsExporter.closures[network.VoteProcessed] = events.NewClosure(metricsExporter.voteProcessed)
+
+	network.VoteReceived.Attach(metricsExporter.closures[network.VoteReceived])
+	network.VoteProcessed.Attach(metricsExporter.closures[network.VoteProcessed])
+
+	return metricsExporter
+}
+
+// Detach stops counting the votes and the opinion flips.
+func (m *MetricsExporter) Detach() {
+	for event, closure := range m.closures {
+		event.Detach(closure)
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *MetricsExporter) ServeHTTP(responseWriter http.ResponseWriter, _ *http.Request) {
+	var buf bytes.Buffer
+	if err := m.WriteMetrics(&buf); err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buf.WriteTo(responseWriter)
+}
+
+// WriteMetrics writes the metrics in the Prometheus text exposition format to the given writer.
+func (m *MetricsExporter) WriteMetrics(writer io.Writer) (err error) {
+	sample := newTimeSeriesSample(m.network, m.network.Clock.Now())
+	metricsWriter := newMetricsWriter(writer)
+
+	m.mutex.Lock()
+	metricsWriter.Metric("metastabilitybreaker_votes_sent_total", "counter", "Number of votes that were sent through the Network.")
+	metricsWriter.Sample("metastabilitybreaker_votes_sent_total", nil, float64(m.votesSent))
+	metricsWriter.Metric("metastabilitybreaker_votes_processed_total", "counter", "Number of votes that the Voters applied to their ApprovalWeightManager.")
+	for _, voterType := range sortedKeys(m.votesProcessed) {
+		metricsWriter.Sample("metastabilitybreaker_votes_processed_total", []string{"voter_type", voterType}, float64(m.votesProcessed[voterType]))
+	}
+	m.mutex.Unlock()
+
+	metricsWriter.Metric("metastabilitybreaker_opinion_flips_total", "counter", "Number of times that an HonestVoter changed its opinion.")
+	metricsWriter.Sample("metastabilitybreaker_opinion_flips_total", nil, float64(m.opinionFlipCounter.OpinionFlips()))
+
+	votersOnline := make(map[string]int)
+	for _, voter := range m.network.Voters() {
+		if voter.Online() {
+			votersOnline[voter.Type()]++
+		}
+	}
+	metricsWriter.Metric("metastabilitybreaker_voters_online", "gauge", "Number of online Voters.")
+	for _, voterType := range sortedKeys(votersOnline) {
+		metricsWriter.Sample("metastabilitybreaker_voters_online", []string{"voter_type", voterType}, float64(votersOnline[voterType]))
+	}
+
+	metricsWriter.Metric("metastabilitybreaker_branch_weight", "gauge", "Approval weight of the Branches per voter type in the perception of the first online HonestVoter.")
+	voterTypes := make([]string, 0, len(sample.BranchWeights))
+	for voterType := range sample.BranchWeights {
+		voterTypes = append(voterTypes, voterType)
+	}
+	sort.Strings(voterTypes)
+	for _, voterType := range voterTypes {
+		weights := sample.BranchWeights[voterType]
+		branchIDs := make(BranchIDs, len(weights))
+		for branchID := range weights {
+			branchIDs[branchID] = types.Void
+		}
+
+		for _, branchID := range branchIDs.Sorted() {
+			metricsWriter.Sample("metastabilitybreaker_branch_weight", []string{"voter_type", voterType, "branch", fmt.Sprintf("%d", branchID)}, weights[branchID])
+		}
+	}
+
+	metricsWriter.Metric("metastabilitybreaker_weight_gap", "gauge", "Difference between the weights of the two competing Branches.")
+	metricsWriter.Sample("metastabilitybreaker_weight_gap", nil, sample.WeightGap)
+	metricsWriter.Metric("metastabilitybreaker_breaker_threshold", "gauge", "Weight gap up to which the metastability breaker favors the lower hash.")
+	metricsWriter.Sample("metastabilitybreaker_breaker_threshold", nil, sample.BreakerThreshold)
+	metricsWriter.Metric("metastabilitybreaker_honest_opinions", "gauge", "Number of distinct Branches that the online HonestVoters last voted for.")
+	metricsWriter.Sample("metastabilitybreaker_honest_opinions", nil, float64(sample.HonestOpinions))
+
+	conflictResolved := m.network.ConflictResolved()
+	activeConflicts := 0
+	if sample.SecondLargestBranch != UndefinedBranchID && !conflictResolved {
+		activeConflicts = 1
+	}
+	metricsWriter.Metric("metastabilitybreaker_conflict_resolved", "gauge", "1 if all online HonestVoters agree on the same Branch.")
+	metricsWriter.Sample("metastabilitybreaker_conflict_resolved", nil, boolToFloat(conflictResolved))
+	metricsWriter.Metric("metastabilitybreaker_active_conflicts", "gauge", "Number of conflicts between Branches that are not resolved yet.")
+	metricsWriter.Sample("metastabilitybreaker_active_conflicts", nil, float64(activeConflicts))
+
+	return metricsWriter.Flush()
+}
+
+func (m *MetricsExporter) voteSent(vote *Vote) {
+	m.opinionFlipCounter.VoteReceived(vote)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.votesSent++
+}
+
+func (m *MetricsExporter) voteProcessed(voter *HonestVoter, _ *Vote) {
+	// attackers that build on top of an HonestVoter trigger the event with the embedded HonestVoter
+	voterType := voter.Type()
+	if networkVoter, exists := m.network.Voter(voter.ID()); exists {
+		voterType = networkVoter.Type()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.votesProcessed[voterType]++
+}
+
+// sortedKeys returns the keys of the given map in ascending order.
+func sortedKeys(counts map[string]int) (keys []string) {
+	keys = make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// boolToFloat returns 1 for true and 0 for false.
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region metricsWriter ////////////////////////////////////////////////////////////////////////////////////////////////
+
+// metricsWriter writes metrics in the Prometheus text exposition format and keeps the first error that occurred.
+type metricsWriter struct {
+	writer *bufio.Writer
+	err    error
+}
+
+// newMetricsWriter returns a metricsWriter that writes to the given writer.
+func newMetricsWriter(writer io.Writer) *metricsWriter {
+	return &metricsWriter{
+		writer: bufio.NewWriter(writer),
+	}
+}
+
+// Metric writes the HELP and TYPE lines of a metric.
+func (m *metricsWriter) Metric(name, metricType, help string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// Sample writes a sample of a metric with the given label names and values (alternating).
+func (m *metricsWriter) Sample(name string, labels []string, value float64) {
+	if len(labels) == 0 {
+		m.printf("%s %s\n", name, formatFloat(value))
+
+		return
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", labels[i], labelValueReplacer.Replace(labels[i+1])))
+	}
+
+	m.printf("%s{%s} %s\n", name, strings.Join(pairs, ","), formatFloat(value))
+}
+
+// Flush writes the buffered metrics and returns the first error that occurred.
+func (m *metricsWriter) Flush() error {
+	if m.err == nil {
+		m.err = m.writer.Flush()
+	}
+
+	return m.err
+}
+
+// printf writes the formatted string unless an error occurred before.
+func (m *metricsWriter) printf(format string, args ...interface{}) {
+	if m.err != nil {
+		return
+	}
+
+	_, m.err = fmt.Fprintf(m.writer, format, args...)
+}
+
+// labelValueReplacer escapes the label values of the Prometheus text exposition format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
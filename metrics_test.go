@@ -0,0 +1,61 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	network := NewNetwork(5 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(1)
+	defer network.Shutdown()
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+
+	metricsExporter := NewMetricsExporter(network)
+	defer metricsExporter.Detach()
+
+	var buf bytes.Buffer
+	require.NoError(t, metricsExporter.WriteMetrics(&buf))
+	assert.Contains(t, buf.String(), "# TYPE metastabilitybreaker_votes_sent_total counter\nmetastabilitybreaker_votes_sent_total 0\n")
+	assert.Contains(t, buf.String(), "metastabilitybreaker_voters_online{voter_type=\"HonestVoter\"} 8\n")
+	assert.Contains(t, buf.String(), "metastabilitybreaker_active_conflicts 0\n")
+
+	resolved, _, err := network.Simulate(time.Minute, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+	require.True(t, resolved)
+
+	server := httptest.NewServer(metricsExporter)
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", response.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	metrics := string(body)
+	assert.NotContains(t, metrics, "metastabilitybreaker_votes_sent_total 0\n")
+	assert.Contains(t, metrics, "metastabilitybreaker_votes_processed_total{voter_type=\"MinorityVoter\"}")
+	assert.Regexp(t, `metastabilitybreaker_branch_weight\{voter_type="HonestVoter",branch="[12]"\} 0\.(8|79)`, metrics)
+	assert.Contains(t, metrics, "metastabilitybreaker_conflict_resolved 1\n")
+	assert.Contains(t, metrics, "metastabilitybreaker_active_conflicts 0\n")
+}
+
+func TestMetricsWriter(t *testing.T) {
+	var buf bytes.Buffer
+	metricsWriter := newMetricsWriter(&buf)
+	metricsWriter.Metric("test_metric", "gauge", "Test metric.")
+	metricsWriter.Sample("test_metric", []string{"label", "a \"quoted\"\\value\n"}, 0.5)
+	require.NoError(t, metricsWriter.Flush())
+
+	assert.Equal(t, "# HELP test_metric Test metric.\n# TYPE test_metric gauge\ntest_metric{label=\"a \\\"quoted\\\"\\\\value\\n\"} 0.5\n", buf.String())
+}
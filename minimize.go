@@ -0,0 +1,359 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iotaledger/hive.go/types"
+)
+
+// region TraceMinimization ////////////////////////////////////////////////////////////////////////////////////////////
+
+// TraceFailure returns true if the outcome of a Replay shows the failure that a TraceMinimization preserves.
+type TraceFailure func(result *ReplayResult) bool
+
+// TraceMinimization shrinks a failing vote trace to a minimal sequence of votes that still fails, so it is
+// possible to see why an attack succeeded. It uses delta debugging (ddmin), so the result is 1-minimal: removing any
+// single remaining vote makes the failure disappear. Every candidate is replayed (see Replay) with the EndTime of the
+// original trace, so the failure has to persist until the end of the original run.
+type TraceMinimization struct {
+	// Trace contains the records of the failing run (see Tracer).
+	Trace []*TraceRecord
+
+	// Setup configures the Network that the candidates are replayed against.
+	Setup func(network *Network)
+
+	// Failure defines the failure that is preserved (nil defaults to (*ReplayResult).Metastable).
+	Failure TraceFailure
+}
+
+// Run minimizes the trace and returns the minimal failing sequence of votes.
+func (t *TraceMinimization) Run() (result *TraceMinimizationResult, err error) {
+	failure := t.Failure
+	if failure == nil {
+		failure = (*ReplayResult).Metastable
+	}
+
+	votes := groupVotes(t.Trace)
+	if len(votes) == 0 {
+		return nil, fmt.Errorf("trace does not contain any processed votes: %w", ErrInvalidParameter)
+	}
+
+	positions := make(map[*TraceRecord]int, len(t.Trace))
+	for i, record := range t.Trace {
+		positions[record] = i
+	}
+
+	result = &TraceMinimizationResult{
+		StartTime:     t.Trace[0].Time,
+		EndTime:       t.Trace[len(t.Trace)-1].Time,
+		OriginalVotes: len(votes),
+	}
+
+	fails := func(candidate []*TraceVote) (failed bool, err error) {
+		result.Replays++
+
+		if result.ReplayResult, err = (&Replay{Trace: processedRecords(candidate, positions), Setup: t.Setup, EndTime: result.EndTime}).Run(); err != nil {
+			return false, err
+		}
+
+		return failure(result.ReplayResult), nil
+	}
+
+	if failed, failsErr := fails(votes); failsErr != nil {
+		return nil, failsErr
+	} else if !failed {
+		return nil, fmt.Errorf("replay of the trace does not show the failure: %w", ErrInvalidParameter)
+	}
+
+	if result.Votes, err = ddmin(votes, fails); err != nil {
+		return nil, err
+	}
+	result.Trace = processedRecords(result.Votes, positions)
+
+	// replay the minimal trace again, as the last replay of ddmin might have been a candidate that did not fail
+	if _, err = fails(result.Votes); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ddmin returns a 1-minimal subsequence of the given votes for which fails returns true (it expects fails to return
+// true for the given votes).
+func ddmin(votes []*TraceVote, fails func(candidate []*TraceVote) (bool, error)) (minimalVotes []*TraceVote, err error) {
+	granularity := 2
+	for len(votes) >= 2 {
+		chunks := splitVotes(votes, granularity)
+
+		reduced := false
+		for i := range chunks {
+			if failed, failsErr := fails(chunks[i]); failsErr != nil {
+				return nil, failsErr
+			} else if failed {
+				votes, granularity, reduced = chunks[i], 2, true
+
+				break
+			}
+
+			if granularity == 2 {
+				// with 2 chunks the complement of a chunk is the other chunk, which is tested anyway
+				continue
+			}
+
+			complement := complementOf(chunks, i)
+			if failed, failsErr := fails(complement); failsErr != nil {
+				return nil, failsErr
+			} else if failed {
+				votes, granularity, reduced = complement, granularity-1, true
+
+				break
+			}
+		}
+
+		if reduced {
+			continue
+		}
+
+		if granularity >= len(votes) {
+			break
+		}
+
+		if granularity *= 2; granularity > len(votes) {
+			granularity = len(votes)
+		}
+	}
+
+	return votes, nil
+}
+
+// splitVotes splits the given votes into the given number of chunks of (nearly) equal size.
+func splitVotes(votes []*TraceVote, chunkCount int) (chunks [][]*TraceVote) {
+	start := 0
+	for i := 0; i < chunkCount; i++ {
+		end := start + (len(votes)-start)/(chunkCount-i)
+		chunks = append(chunks, votes[start:end])
+		start = end
+	}
+
+	return chunks
+}
+
+// complementOf returns the votes of all chunks except the one with the given index.
+func complementOf(chunks [][]*TraceVote, index int) (complement []*TraceVote) {
+	for i, chunk := range chunks {
+		if i != index {
+			complement = append(complement, chunk...)
+		}
+	}
+
+	return complement
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TraceVote ////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TraceVote is a single vote of a trace together with the records of all Voters that processed it. A TraceMinimization
+// removes whole votes, so a removed vote disappears from the perception of all Voters at once.
+type TraceVote struct {
+	// Sent is the record of the sent vote (nil for votes that were delivered without being sent through the Network).
+	Sent *TraceRecord
+
+	// Processed contains the records of the Voters that processed the vote.
+	Processed []*TraceRecord
+}
+
+// groupVotes returns the votes of the given trace in the order in which they were sent. A processed record belongs to
+// the last vote of its issuer for its Branch that was sent before it.
+func groupVotes(trace []*TraceRecord) (votes []*TraceVote) {
+	type voteKey struct {
+		issuer   VoterID
+		branchID BranchID
+	}
+
+	lastVotes := make(map[voteKey]*TraceVote)
+	for _, record := range trace {
+		key := voteKey{issuer: record.Issuer, branchID: record.BranchID}
+
+		switch record.Event {
+		case TraceEventSent:
+			lastVotes[key] = &TraceVote{Sent: record}
+			votes = append(votes, lastVotes[key])
+		case TraceEventProcessed:
+			vote, exists := lastVotes[key]
+			if !exists {
+				vote = &TraceVote{}
+				lastVotes[key] = vote
+				votes = append(votes, vote)
+			}
+			vote.Processed = append(vote.Processed, record)
+		}
+	}
+
+	// votes that no Voter processed are irrelevant for the decisions
+	processedVotes := votes[:0]
+	for _, vote := range votes {
+		if len(vote.Processed) != 0 {
+			processedVotes = append(processedVotes, vote)
+		}
+	}
+
+	return processedVotes
+}
+
+// processedRecords returns the processed records of the given votes in the order of their positions in the trace.
+func processedRecords(votes []*TraceVote, positions map[*TraceRecord]int) (records []*TraceRecord) {
+	for _, vote := range votes {
+		records = append(records, vote.Processed...)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return positions[records[i]] < positions[records[j]] })
+
+	return records
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TraceMinimizationResult //////////////////////////////////////////////////////////////////////////////////////
+
+// TraceMinimizationResult contains the minimal failing sequence of votes of a TraceMinimization.
+type TraceMinimizationResult struct {
+	// StartTime is the time of the first record of the original trace.
+	StartTime time.Time
+
+	// EndTime is the time of the last record of the original trace.
+	EndTime time.Time
+
+	// OriginalVotes is the number of processed votes of the original trace.
+	OriginalVotes int
+
+	// Votes contains the minimal failing sequence of votes.
+	Votes []*TraceVote
+
+	// Trace contains the processed records of the minimal failing sequence of votes (it can be replayed on its own).
+	Trace []*TraceRecord
+
+	// Replays is the number of replays that the minimization needed.
+	Replays int
+
+	// ReplayResult is the outcome of the replay of the minimal trace.
+	ReplayResult *ReplayResult
+}
+
+// Narrative returns a human-readable description of the minimal trace and of the failure that it causes.
+func (t *TraceMinimizationResult) Narrative() string {
+	decisions := make(map[*TraceRecord]*ReplayDecision, len(t.Trace))
+	for i, record := range t.Trace {
+		decisions[record] = t.ReplayResult.Decisions[i]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "The failure is caused by %d of the %d votes (found in %d replays):\n", len(t.Votes), t.OriginalVotes, t.Replays)
+	for i, vote := range t.Votes {
+		issuerRecord := vote.Sent
+		if issuerRecord == nil {
+			issuerRecord = vote.Processed[0]
+		}
+
+		fmt.Fprintf(&buf, "\n%3d. t=%s: %s %s (weight %0.2f) votes for %s.\n",
+			i+1,
+			issuerRecord.Time.Sub(t.StartTime),
+			issuerTypeOf(issuerRecord), issuerRecord.Issuer, issuerRecord.IssuerWeight,
+			issuerRecord.BranchID,
+		)
+
+		for _, record := range vote.Processed {
+			decision := decisions[record]
+
+			fmt.Fprintf(&buf, "     t=%s: %s %s perceives %s and favors %s.\n",
+				record.Time.Sub(t.StartTime),
+				record.VoterType, record.Voter,
+				describeWeights(decision.Weights),
+				decision.ReplayedBranch,
+			)
+		}
+	}
+
+	fmt.Fprintf(&buf, "\nAt the end of the trace (t=%s) ", t.EndTime.Sub(t.StartTime))
+	honestDecisions := t.ReplayResult.HonestDecisions()
+	if len(honestDecisions) > 1 {
+		buf.WriteString("the HonestVoters are still split:")
+	} else {
+		buf.WriteString("the HonestVoters agree:")
+	}
+	for _, branchID := range branchIDsOf(honestDecisions).Sorted() {
+		fmt.Fprintf(&buf, " %s is favored by %s;", branchID, describeVoterIDs(honestDecisions[branchID]))
+	}
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteString(".\n")
+
+	if t.ReplayResult.SafetyViolated() {
+		fmt.Fprintf(&buf, "Safety is violated as the HonestVoters confirmed the conflicting Branches %s.\n", describeBranchIDs(t.ReplayResult.ConfirmedBranches().Sorted()))
+	}
+
+	return buf.String()
+}
+
+func (t *TraceMinimizationResult) String() string {
+	return t.Narrative()
+}
+
+// issuerTypeOf returns the type of the issuer of the given record (the initial votes have no issuer).
+func issuerTypeOf(record *TraceRecord) string {
+	if record.IssuerType == "" {
+		return "initial"
+	}
+
+	return record.IssuerType
+}
+
+// describeWeights returns a human-readable description of the given weights of the Branches.
+func describeWeights(weights map[BranchID]float64) string {
+	branchIDs := make(BranchIDs, len(weights))
+	for branchID := range weights {
+		branchIDs[branchID] = types.Void
+	}
+
+	descriptions := make([]string, 0, len(weights))
+	for _, branchID := range branchIDs.Sorted() {
+		descriptions = append(descriptions, fmt.Sprintf("%s=%0.2f", branchID, weights[branchID]))
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// branchIDsOf returns the Branches that the given HonestVoters favor.
+func branchIDsOf(decisions map[BranchID][]VoterID) (branchIDs BranchIDs) {
+	branchIDs = make(BranchIDs, len(decisions))
+	for branchID := range decisions {
+		branchIDs[branchID] = types.Void
+	}
+
+	return branchIDs
+}
+
+// describeVoterIDs returns a human-readable list of the given Voters.
+func describeVoterIDs(voterIDs []VoterID) string {
+	descriptions := make([]string, len(voterIDs))
+	for i, voterID := range voterIDs {
+		descriptions[i] = voterID.String()
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// describeBranchIDs returns a human-readable list of the given Branches.
+func describeBranchIDs(branchIDs []BranchID) string {
+	descriptions := make([]string, len(branchIDs))
+	for i, branchID := range branchIDs {
+		descriptions[i] = branchID.String()
+	}
+
+	return strings.Join(descriptions, " and ")
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
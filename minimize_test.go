@@ -0,0 +1,102 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/hive.go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceMinimization(t *testing.T) {
+	trace := traceSplitMinorityVoter(t, 5)
+	setup := func(network *Network) {
+		network.MetastabilityBreakingThreshold = 5 * time.Second
+		network.PerceptionNoise.MaxWeightDeviation = 0.05
+	}
+
+	result, err := (&TraceMinimization{Trace: trace, Setup: setup}).Run()
+	require.NoError(t, err)
+	assert.Less(t, len(result.Votes), result.OriginalVotes)
+	assert.True(t, result.ReplayResult.Metastable())
+	assert.Contains(t, result.Narrative(), "the HonestVoters are still split")
+
+	// the minimal trace is 1-minimal
+	positions := make(map[*TraceRecord]int)
+	for i, record := range result.Trace {
+		positions[record] = i
+	}
+	for i := range result.Votes {
+		candidate := append(append([]*TraceVote{}, result.Votes[:i]...), result.Votes[i+1:]...)
+
+		replayResult, err := (&Replay{Trace: processedRecords(candidate, positions), Setup: setup, EndTime: result.EndTime}).Run()
+		require.NoError(t, err)
+		assert.False(t, replayResult.Metastable(), "removing vote %d should end the metastable state", i+1)
+	}
+
+	// the minimal trace can be written and replayed on its own
+	var buf bytes.Buffer
+	require.NoError(t, WriteTrace(&buf, result.Trace))
+	minimalTrace, err := ReadTrace(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, result.Trace, minimalTrace)
+
+	// a trace that does not violate safety can not be minimized for a safety violation
+	_, err = (&TraceMinimization{Trace: trace, Setup: setup, Failure: (*ReplayResult).SafetyViolated}).Run()
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestDDMin(t *testing.T) {
+	votes := make([]*TraceVote, 16)
+	for i := range votes {
+		votes[i] = &TraceVote{Sent: &TraceRecord{BranchID: NewBranchID(i)}}
+	}
+
+	// fails if the votes contain both BranchID(3) and BranchID(12)
+	minimalVotes, err := ddmin(votes, func(candidate []*TraceVote) (bool, error) {
+		found := make(BranchIDs)
+		for _, vote := range candidate {
+			found[vote.Sent.BranchID] = types.Void
+		}
+		_, contains3 := found[NewBranchID(3)]
+		_, contains12 := found[NewBranchID(12)]
+
+		return contains3 && contains12, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*TraceVote{votes[3], votes[12]}, minimalVotes)
+
+	_, err = ddmin(votes, func(candidate []*TraceVote) (bool, error) {
+		return false, fmt.Errorf("replay failed")
+	})
+	assert.Error(t, err)
+}
+
+// traceSplitMinorityVoter returns the trace of a short simulated run with noisy perceptions that ends with the
+// HonestVoters favoring different Branches.
+func traceSplitMinorityVoter(t *testing.T, seed int64) (records []*TraceRecord) {
+	var buf bytes.Buffer
+
+	network := NewNetwork(5 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(seed)
+	network.PerceptionNoise.MaxUpdateDelay = 200 * time.Millisecond
+	network.PerceptionNoise.MaxWeightDeviation = 0.05
+	defer network.Shutdown()
+	tracer := NewTracer(network, &buf)
+	network.AddVoters(8, NewHonestVoter, FixedWeight(0.1))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+	resolved, _, err := network.Simulate(500*time.Millisecond, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+	require.False(t, resolved)
+	tracer.Detach()
+	require.NoError(t, tracer.Err())
+
+	records, err = ReadTrace(&buf)
+	require.NoError(t, err)
+
+	return records
+}
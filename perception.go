@@ -0,0 +1,110 @@
+package metastabilitybreaker
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// region PerceptionNoise //////////////////////////////////////////////////////////////////////////////////////////////
+
+// PerceptionNoise configures how much the perception of the Voters diverges. The zero value disables the noise, so all
+// Voters see the exact same weights at the same time.
+type PerceptionNoise struct {
+	// MaxWeightDeviation bounds the deviation that every Voter perceives for the weight of each Branch.
+	MaxWeightDeviation float64
+
+	// MaxUpdateDelay bounds the random delay with which a Voter processes the votes of other Voters.
+	MaxUpdateDelay time.Duration
+}
+
+// updateDelay returns a random delay for the processing of a vote.
+func (p PerceptionNoise) updateDelay(random *rand.Rand) time.Duration {
+	if p.MaxUpdateDelay <= 0 {
+		return 0
+	}
+
+	return time.Duration(random.Int63n(int64(p.MaxUpdateDelay)))
+}
+
+func (p PerceptionNoise) String() string {
+	return fmt.Sprintf("±%0.2f / %s", p.MaxWeightDeviation, p.MaxUpdateDelay)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region noisyPerception //////////////////////////////////////////////////////////////////////////////////////////////
+
+// noisyPerception is a perception that adds a bounded, per Branch deviation to the weights of another perception.
+type noisyPerception struct {
+	Perception
+
+	weightDeviations *weightDeviations
+}
+
+func (n *noisyPerception) Weight(branchID BranchID) float64 {
+	return n.Perception.Weight(branchID) + n.weightDeviations.Deviation(branchID)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region weightDeviations /////////////////////////////////////////////////////////////////////////////////////////////
+
+// weightDeviations stores the deviations that a single Voter perceives for the weights of the Branches. Each deviation
+// is drawn once, so the perception of a Voter is consistently (and not randomly) off.
+type weightDeviations struct {
+	network    *Network
+	deviations map[BranchID]float64
+	mutex      sync.Mutex
+}
+
+// newWeightDeviations returns a new weightDeviations instance that draws the deviations within the bound of the
+// PerceptionNoise of the given Network.
+func newWeightDeviations(network *Network) *weightDeviations {
+	return &weightDeviations{
+		network:    network,
+		deviations: make(map[BranchID]float64),
+	}
+}
+
+// Deviation returns the deviation that is perceived for the weight of the given Branch.
+func (w *weightDeviations) Deviation(branchID BranchID) float64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	deviation, exists := w.deviations[branchID]
+	if !exists {
+		deviation = (2*w.network.Random.Float64() - 1) * w.network.PerceptionNoise.MaxWeightDeviation
+		w.deviations[branchID] = deviation
+	}
+
+	return deviation
+}
+
+// Deviations returns a copy of the deviations that were drawn so far.
+func (w *weightDeviations) Deviations() (deviations map[BranchID]float64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	deviations = make(map[BranchID]float64, len(w.deviations))
+	for branchID, deviation := range w.deviations {
+		deviations[branchID] = deviation
+	}
+
+	return deviations
+}
+
+// Restore sets the deviations of the Branches that were not drawn yet to the given values (e.g. to replay a trace).
+func (w *weightDeviations) Restore(deviations map[BranchID]float64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for branchID, deviation := range deviations {
+		if _, exists := w.deviations[branchID]; !exists {
+			w.deviations[branchID] = deviation
+		}
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
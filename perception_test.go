@@ -0,0 +1,82 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightDeviations(t *testing.T) {
+	network := NewNetwork(0)
+	network.PerceptionNoise.MaxWeightDeviation = 0.1
+	weightDeviations := newWeightDeviations(network)
+
+	for branchID := BranchID(1); branchID <= 100; branchID++ {
+		deviation := weightDeviations.Deviation(branchID)
+		assert.LessOrEqual(t, deviation, 0.1)
+		assert.GreaterOrEqual(t, deviation, -0.1)
+		assert.Equal(t, deviation, weightDeviations.Deviation(branchID), "the deviation of a Branch should not change")
+	}
+}
+
+func TestPerceptionNoise_VoteOrder(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	clock := NewSimulatedClock(simulationStartTime)
+	network.Clock = clock
+	network.Random = NewRandom(1)
+	network.PerceptionNoise = PerceptionNoise{MaxUpdateDelay: 1 * time.Second}
+	network.AddVoters(2, NewHonestVoter, FixedWeight(0.5))
+	issuer, recipient := network.Voters()[0], network.Voters()[1]
+
+	for i := 0; i < 20; i++ {
+		network.VoteReceived.Trigger(&Vote{Issuer: issuer.ID(), BranchID: NewBranchID(2)})
+		network.VoteReceived.Trigger(&Vote{Issuer: issuer.ID(), BranchID: NewBranchID(1)})
+		clock.Sleep(1 * time.Second)
+
+		lastStatement, _ := recipient.ApprovalWeightManager().LastStatement(issuer.ID())
+		assert.Equal(t, NewBranchID(1), lastStatement, "the delay should not reorder the votes of an issuer")
+	}
+}
+
+func TestPerceptionNoise_Attacker(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.PerceptionNoise = PerceptionNoise{MaxWeightDeviation: 0.2, MaxUpdateDelay: 1 * time.Second}
+	network.AddVoters(1, NewHonestVoter, FixedWeight(0.6))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.4))
+	honestVoter, minorityVoter := network.Voters()[0], network.Voters()[1]
+
+	network.VoteReceived.Trigger(&Vote{Issuer: honestVoter.ID(), BranchID: NewBranchID(1)})
+	network.VoteReceived.Trigger(&Vote{Issuer: minorityVoter.ID(), BranchID: NewBranchID(2)})
+
+	perception := minorityVoter.(*MinorityVoter).consensus.livePerception()
+	assert.Equal(t, 0.6, perception.Weight(NewBranchID(1)), "an attacker should process votes without delay or deviation")
+	assert.Equal(t, 0.4, perception.Weight(NewBranchID(2)), "an attacker should process votes without delay or deviation")
+	assert.NotEqual(t, 0.6, honestVoter.(*HonestVoter).consensus.livePerception().Weight(NewBranchID(1)))
+}
+
+func TestSweep_PerceptionNoise(t *testing.T) {
+	sweep := &Sweep{
+		Scenario:                        NewSweepScenario(NewMinorityVoter),
+		MetastabilityBreakingThresholds: []time.Duration{1 * time.Second},
+		AttackerWeights:                 []float64{0.2},
+		HonestVoters:                    []int{8},
+		Latencies:                       []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond},
+		WeightDeviations:                []float64{0, 0.05, 0.1},
+		Runs:                            20,
+		Seed:                            1,
+		BranchIDs:                       []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:                         10 * time.Second,
+	}
+
+	results, err := sweep.Run()
+	require.NoError(t, err)
+	require.Len(t, results, 9)
+
+	for _, result := range results {
+		assert.Equal(t, float64(1), result.ResolutionProbability, "failed to resolve with a latency of %s and a weight deviation of %0.2f", result.Latency, result.WeightDeviation)
+	}
+
+	t.Logf("\n%s", results)
+}
@@ -0,0 +1,49 @@
+package metastabilitybreaker
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// region NewRandom ////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewRandom returns a source of randomness with the given seed that is safe for concurrent use. Two Networks that use
+// sources with the same seed draw the same random numbers, which makes their simulations reproducible.
+func NewRandom(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{
+		source: rand.NewSource(seed).(rand.Source64),
+	})
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region lockedSource /////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lockedSource is a rand.Source64 that can be used by multiple goroutines at the same time.
+type lockedSource struct {
+	source rand.Source64
+	mutex  sync.Mutex
+}
+
+func (l *lockedSource) Int63() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.source.Int63()
+}
+
+func (l *lockedSource) Uint64() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.source.Uint64()
+}
+
+func (l *lockedSource) Seed(seed int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.source.Seed(seed)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,265 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iotaledger/hive.go/types"
+	"github.com/olekukonko/tablewriter"
+)
+
+// region Replay ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Replay feeds the votes of a recorded trace into a fresh Network in the exact order and at the exact (simulated) times
+// in which they were processed, and compares the decisions of the Voters with the recorded ones. Replaying a trace
+// with the original parameters reproduces the recorded decisions, while a different ConsensusRule (or different
+// parameters) shows where a change would have led to a different outcome for the same adversarial sequence of votes.
+//
+// The Voters perceive the weight deviations that were recorded in the trace as long as the Network of the replay has
+// a MaxWeightDeviation.
+//
+// The Replay is open-loop: the Voters do not issue votes of their own, and the votes of the honest Voters are replayed
+// as they were recorded even if their replayed decisions diverge. A divergence therefore only shows the first point
+// at which a change would have made a Voter decide differently, but not how the rest of the run would have unfolded.
+type Replay struct {
+	// Trace contains the records of the recorded run (see Tracer).
+	Trace []*TraceRecord
+
+	// Setup configures the Network that the trace is replayed against (e.g. its MetastabilityBreakingThreshold or its
+	// ConsensusRule). The Voters are created from the trace, so Voters that Setup adds do not take part in the replay.
+	Setup func(network *Network)
+
+	// EndTime is the time at which the FinalDecisions are made (the zero value defaults to the time of the last
+	// record).
+	EndTime time.Time
+}
+
+// Run replays the trace and returns the recorded and the replayed decisions.
+func (r *Replay) Run() (result *ReplayResult, err error) {
+	if len(r.Trace) == 0 {
+		return nil, fmt.Errorf("trace is empty: %w", ErrInvalidParameter)
+	}
+
+	startTime := r.Trace[0].Time
+	clock := NewSimulatedClock(startTime)
+	network := NewNetwork(0)
+	network.Clock = clock
+	network.Random = NewRandom(0)
+	if r.Setup != nil {
+		r.Setup(network)
+	}
+	defer network.Shutdown()
+
+	for _, record := range r.Trace {
+		network.WeightDistribution.SetWeight(record.Issuer, record.IssuerWeight)
+	}
+
+	result = newReplayResult()
+	voters := make(map[VoterID]*HonestVoter)
+	for i, record := range r.Trace {
+		if record.Time.Before(clock.Now()) {
+			return nil, fmt.Errorf("record %d of the trace is out of order: %w", i, ErrInvalidParameter)
+		}
+		clock.Sleep(record.Time.Sub(clock.Now()))
+
+		if record.Event != TraceEventProcessed {
+			continue
+		}
+
+		voter, exists := voters[record.Voter]
+		if !exists {
+			voter = newReplayedVoter(network, record.Voter, record.VoterIsAttacker)
+			voters[record.Voter] = voter
+			result.voterTypes[record.Voter] = record.VoterType
+		}
+
+		voter.approvalWeightManager.ProcessVote(&Vote{Issuer: record.Issuer, BranchID: record.BranchID})
+		voter.consensus.weightDeviations.Restore(record.WeightDeviations)
+
+		decision := &ReplayDecision{
+			Time:           record.Time.Sub(startTime),
+			Voter:          record.Voter,
+			VoterType:      record.VoterType,
+			Vote:           &Vote{Issuer: record.Issuer, BranchID: record.BranchID},
+			Weights:        voter.approvalWeightManager.Snapshot().Weights(),
+			RecordedBranch: record.FavoredBranch,
+			ReplayedBranch: voter.consensus.FavoredBranch(),
+		}
+		result.Decisions = append(result.Decisions, decision)
+
+		for branchID, weight := range decision.Weights {
+			if weight >= network.ConfirmationThreshold && record.VoterType == "HonestVoter" {
+				result.confirmedBranches[branchID] = types.Void
+			}
+		}
+	}
+
+	if r.EndTime.After(clock.Now()) {
+		clock.Sleep(r.EndTime.Sub(clock.Now()))
+	}
+	for voterID, voter := range voters {
+		result.finalDecisions[voterID] = voter.consensus.FavoredBranch()
+	}
+
+	return result, nil
+}
+
+// newReplayedVoter returns an HonestVoter with the given identifier that only processes the replayed votes (perceiving
+// the exact weights if it replays an Attacker).
+func newReplayedVoter(network *Network, voterID VoterID, attacker bool) (voter *HonestVoter) {
+	voter = NewHonestVoter(network).(*HonestVoter)
+	voter.id = voterID
+	voter.consensus.exactPerception = attacker
+
+	return voter
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region ReplayResult /////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReplayResult contains the decisions of a Replay.
+type ReplayResult struct {
+	// Decisions are the decisions of the Voters after each processed vote in the order of the trace.
+	Decisions []*ReplayDecision
+
+	voterTypes        map[VoterID]string
+	finalDecisions    map[VoterID]BranchID
+	confirmedBranches BranchIDs
+}
+
+// newReplayResult returns an empty ReplayResult.
+func newReplayResult() *ReplayResult {
+	return &ReplayResult{
+		voterTypes:        make(map[VoterID]string),
+		finalDecisions:    make(map[VoterID]BranchID),
+		confirmedBranches: make(BranchIDs),
+	}
+}
+
+// Divergences returns the decisions in which the replayed Branch differs from the recorded one.
+func (r *ReplayResult) Divergences() (divergences []*ReplayDecision) {
+	for _, decision := range r.Decisions {
+		if decision.Diverged() {
+			divergences = append(divergences, decision)
+		}
+	}
+
+	return divergences
+}
+
+// Reproduced returns true if all replayed decisions match the recorded ones.
+func (r *ReplayResult) Reproduced() bool {
+	return len(r.Divergences()) == 0
+}
+
+// FinalDecisions returns the Branch that each Voter favors at the EndTime of the replay.
+func (r *ReplayResult) FinalDecisions() (finalDecisions map[VoterID]BranchID) {
+	finalDecisions = make(map[VoterID]BranchID, len(r.finalDecisions))
+	for voterID, branchID := range r.finalDecisions {
+		finalDecisions[voterID] = branchID
+	}
+
+	return finalDecisions
+}
+
+// HonestDecisions returns the HonestVoters grouped by the Branch that they favor at the EndTime of the replay.
+func (r *ReplayResult) HonestDecisions() (honestDecisions map[BranchID][]VoterID) {
+	honestDecisions = make(map[BranchID][]VoterID)
+	for voterID, branchID := range r.finalDecisions {
+		if r.voterTypes[voterID] == "HonestVoter" {
+			honestDecisions[branchID] = append(honestDecisions[branchID], voterID)
+		}
+	}
+
+	for _, voterIDs := range honestDecisions {
+		sort.Slice(voterIDs, func(i, j int) bool { return voterIDs[i] < voterIDs[j] })
+	}
+
+	return honestDecisions
+}
+
+// Metastable returns true if the HonestVoters still favor different Branches at the EndTime of the replay.
+func (r *ReplayResult) Metastable() bool {
+	return len(r.HonestDecisions()) > 1
+}
+
+// ConfirmedBranches returns the Branches whose weight reached the ConfirmationThreshold in the perception of at least
+// one HonestVoter during the replay.
+func (r *ReplayResult) ConfirmedBranches() (confirmedBranches BranchIDs) {
+	confirmedBranches = make(BranchIDs, len(r.confirmedBranches))
+	for branchID := range r.confirmedBranches {
+		confirmedBranches[branchID] = types.Void
+	}
+
+	return confirmedBranches
+}
+
+// SafetyViolated returns true if the HonestVoters confirmed more than one of the conflicting Branches.
+func (r *ReplayResult) SafetyViolated() bool {
+	return len(r.confirmedBranches) > 1
+}
+
+func (r *ReplayResult) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d decisions, %d diverged\n", len(r.Decisions), len(r.Divergences()))
+
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"Time", "Voter", "VoterType", "Vote", "RecordedBranch", "ReplayedBranch"})
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, decision := range r.Divergences() {
+		table.Append([]string{
+			decision.Time.String(),
+			decision.Voter.String(),
+			decision.VoterType,
+			decision.Vote.String(),
+			decision.RecordedBranch.String(),
+			decision.ReplayedBranch.String(),
+		})
+	}
+
+	table.Render()
+
+	return buf.String()
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region ReplayDecision ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReplayDecision is the decision of a Voter after processing a single vote of the trace.
+type ReplayDecision struct {
+	// Time is the time since the start of the trace.
+	Time time.Duration
+
+	// Voter is the Voter that processed the vote.
+	Voter VoterID
+
+	// VoterType is the type of the Voter in the recorded run.
+	VoterType string
+
+	// Vote is the processed vote.
+	Vote *Vote
+
+	// Weights are the weights of the Branches that the Voter perceives in the replay after processing the vote.
+	Weights map[BranchID]float64
+
+	// RecordedBranch is the Branch that the Voter favored in the recorded run.
+	RecordedBranch BranchID
+
+	// ReplayedBranch is the Branch that the Voter favors in the replay.
+	ReplayedBranch BranchID
+}
+
+// Diverged returns true if the replayed decision differs from the recorded one.
+func (r *ReplayDecision) Diverged() bool {
+	return r.RecordedBranch != r.ReplayedBranch
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,84 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	// without the metastability breaker some HonestVoters favor BranchID(2) for a while
+	trace, err := ReadTrace(bytes.NewReader(traceMinorityVoter(t, 0, 0, 5)))
+	require.NoError(t, err)
+
+	result, err := (&Replay{Trace: trace}).Run()
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Decisions)
+	assert.True(t, result.Reproduced(), result.String())
+
+	result, err = (&Replay{
+		Trace: trace,
+		Setup: func(network *Network) {
+			network.MetastabilityBreakingThreshold = 100 * time.Millisecond
+		},
+	}).Run()
+	require.NoError(t, err)
+	require.False(t, result.Reproduced(), "the metastability breaker should change the decisions")
+	for _, divergence := range result.Divergences() {
+		assert.Equal(t, NewBranchID(2), divergence.RecordedBranch)
+		assert.Equal(t, NewBranchID(1), divergence.ReplayedBranch, "the metastability breaker should favor the lower hash")
+	}
+
+	result, err = (&Replay{
+		Trace: trace,
+		Setup: func(network *Network) {
+			network.MetastabilityBreakingThreshold = 100 * time.Millisecond
+			network.ConsensusRule = HeaviestBranchRule()
+		},
+	}).Run()
+	require.NoError(t, err)
+	assert.True(t, result.Reproduced(), "the HeaviestBranchRule should ignore the metastability breaker")
+}
+
+func TestReplay_WeightDeviations(t *testing.T) {
+	trace, err := ReadTrace(bytes.NewReader(traceMinorityVoter(t, time.Second, 0.1, 1)))
+	require.NoError(t, err)
+
+	result, err := (&Replay{
+		Trace: trace,
+		Setup: func(network *Network) {
+			network.MetastabilityBreakingThreshold = time.Second
+			network.PerceptionNoise.MaxWeightDeviation = 0.1
+		},
+	}).Run()
+	require.NoError(t, err)
+	assert.True(t, result.Reproduced(), result.String())
+}
+
+func TestReplay_EmptyTrace(t *testing.T) {
+	_, err := (&Replay{}).Run()
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestHeaviestBranchRule(t *testing.T) {
+	network := NewNetwork(time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.ConsensusRule = HeaviestBranchRule()
+	network.AddVoters(1, NewHonestVoter, FixedWeight(0.4))
+	network.AddVoters(1, NewHonestVoter, FixedWeight(0.6))
+	voters := network.Voters()
+
+	honestVoter := voters[0].(*HonestVoter)
+	honestVoter.approvalWeightManager.ProcessVote(&Vote{Issuer: voters[0].ID(), BranchID: NewBranchID(1)})
+	honestVoter.approvalWeightManager.ProcessVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(2)})
+	network.Clock.Sleep(time.Hour)
+
+	// the weight gap is within the lower hash window, but the rule ignores the breaker
+	assert.Equal(t, NewBranchID(2), honestVoter.consensus.FavoredBranch())
+
+	network.ConsensusRule = nil
+	assert.Equal(t, NewBranchID(1), honestVoter.consensus.FavoredBranch())
+}
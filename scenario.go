@@ -0,0 +1,658 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidScenario is returned when a scenario definition can not be turned into a Network.
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// region Scenario /////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Scenario is the declarative definition of a simulation. It is loaded from YAML (or JSON, which is valid YAML) files
+// so experiments can be defined and shared without writing Go code.
+type Scenario struct {
+	// Name is the name of the scenario.
+	Name string `yaml:"name"`
+
+	// Description explains what the scenario is about.
+	Description string `yaml:"description"`
+
+	// MetastabilityBreakingThreshold is the MetastabilityBreakingThreshold of the Network (0 disables the breaker).
+	MetastabilityBreakingThreshold Duration `yaml:"metastabilityBreakingThreshold"`
+
+	// ConfirmationThreshold is the ConfirmationThreshold of the Network (defaults to DefaultConfirmationThreshold).
+	ConfirmationThreshold *float64 `yaml:"confirmationThreshold"`
+
+	// BreakerCeiling is the BreakerCeiling of the Network (defaults to DefaultBreakerCeiling).
+	BreakerCeiling *float64 `yaml:"breakerCeiling"`
+
+	// TimeScaling is the TimeScaling of the Network (defaults to the linear one).
+	TimeScaling *TimeScalingDefinition `yaml:"timeScaling"`
+
+	// StatementTTL is the StatementTTL of the Network.
+	StatementTTL Duration `yaml:"statementTTL"`
+
+	// HeartbeatInterval is the HeartbeatInterval of the Network.
+	HeartbeatInterval Duration `yaml:"heartbeatInterval"`
+
+	// Latency is the maximum delay with which votes are processed (the MaxUpdateDelay of the PerceptionNoise).
+	Latency Duration `yaml:"latency"`
+
+	// WeightDeviation is the MaxWeightDeviation of the PerceptionNoise.
+	WeightDeviation float64 `yaml:"weightDeviation"`
+
+	// NormalizeWeights scales the weights of all Voters so they sum up to 1.0.
+	NormalizeWeights bool `yaml:"normalizeWeights"`
+
+	// Voters are the groups of Voters that take part in the scenario.
+	Voters []*VoterDefinition `yaml:"voters"`
+
+	// Branches are the conflicting Branches that are introduced at the start.
+	Branches []int `yaml:"branches"`
+
+	// Timeout is the (simulated) time after which a run is considered as not resolved.
+	Timeout Duration `yaml:"timeout"`
+
+	// Success defines when a run of the scenario is considered successful.
+	Success SuccessCriteria `yaml:"success"`
+
+	baseDir string
+}
+
+// LoadScenario reads the scenario definition from the file with the given path. Relative paths within the definition
+// (e.g. of CSV weight files) are resolved relative to the directory of the file.
+func LoadScenario(path string) (scenario *Scenario, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file %s: %w", path, err)
+	}
+
+	if scenario, err = ParseScenario(data); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario file %s: %w", path, err)
+	}
+	scenario.baseDir = filepath.Dir(path)
+
+	return scenario, nil
+}
+
+// ParseScenario parses the given YAML or JSON scenario definition. Unknown fields are rejected to catch typos early.
+func ParseScenario(data []byte) (scenario *Scenario, err error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	scenario = &Scenario{}
+	if err = decoder.Decode(scenario); err != nil {
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidScenario)
+	}
+
+	return scenario, nil
+}
+
+// BranchIDs returns the identifiers of the conflicting Branches of the scenario.
+func (s *Scenario) BranchIDs() (branchIDs []BranchID) {
+	for _, branch := range s.Branches {
+		branchIDs = append(branchIDs, NewBranchID(branch))
+	}
+
+	return branchIDs
+}
+
+// Setup validates the scenario and returns a function that configures a Network and adds the Voters of the scenario
+// to it. The function can be applied to any number of Networks (e.g. the runs of a Batch).
+func (s *Scenario) Setup() (setup func(network *Network), err error) {
+	if len(s.Voters) == 0 {
+		return nil, fmt.Errorf("scenario does not define any voters: %w", ErrInvalidScenario)
+	}
+	if len(s.Branches) == 0 {
+		return nil, fmt.Errorf("scenario does not define any branches: %w", ErrInvalidScenario)
+	}
+	if s.Timeout <= 0 {
+		return nil, fmt.Errorf("scenario needs a positive timeout: %w", ErrInvalidScenario)
+	}
+
+	var timeScaling TimeScaling
+	if s.TimeScaling != nil {
+		if timeScaling, err = s.TimeScaling.TimeScaling(); err != nil {
+			return nil, err
+		}
+	}
+
+	voterGroups := make([]func(network *Network), len(s.Voters))
+	for i, voterDefinition := range s.Voters {
+		if voterGroups[i], err = voterDefinition.setup(s.baseDir); err != nil {
+			return nil, fmt.Errorf("voters[%d]: %w", i, err)
+		}
+	}
+
+	setup = func(network *Network) {
+		network.MetastabilityBreakingThreshold = time.Duration(s.MetastabilityBreakingThreshold)
+		if s.ConfirmationThreshold != nil {
+			network.ConfirmationThreshold = *s.ConfirmationThreshold
+		}
+		if s.BreakerCeiling != nil {
+			network.BreakerCeiling = *s.BreakerCeiling
+		}
+		network.TimeScaling = timeScaling
+		network.StatementTTL = time.Duration(s.StatementTTL)
+		network.HeartbeatInterval = time.Duration(s.HeartbeatInterval)
+		network.PerceptionNoise = PerceptionNoise{
+			MaxWeightDeviation: s.WeightDeviation,
+			MaxUpdateDelay:     time.Duration(s.Latency),
+		}
+
+		for _, addVoterGroup := range voterGroups {
+			addVoterGroup(network)
+		}
+
+		if s.NormalizeWeights {
+			network.WeightDistribution.Normalize()
+		}
+	}
+
+	network := NewNetwork(0)
+	setup(network)
+	if err = network.ValidateParameters(); err != nil {
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidScenario)
+	}
+	if err = network.ValidateWeightDistribution(); err != nil {
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidScenario)
+	}
+
+	return setup, nil
+}
+
+// NewNetwork returns a new Network that is set up according to the scenario.
+func (s *Scenario) NewNetwork() (network *Network, err error) {
+	setup, err := s.Setup()
+	if err != nil {
+		return nil, err
+	}
+
+	network = NewNetwork(0)
+	setup(network)
+
+	return network, nil
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region VoterDefinition //////////////////////////////////////////////////////////////////////////////////////////////
+
+// VoterDefinition describes a group of Voters of the same type.
+type VoterDefinition struct {
+	// Type is the name of a registered voter type (e.g. HonestVoter or MinorityVoter).
+	Type string `yaml:"type"`
+
+	// Amount is the number of Voters in the group (it defaults to the number of weights of a sequential or csv weight
+	// definition and is required for all other weight definitions).
+	Amount int `yaml:"amount"`
+
+	// Weights defines how the weight is distributed between the Voters of the group.
+	Weights WeightDefinition `yaml:"weights"`
+
+	// Fault makes the Voters of the group faulty (only for HonestVoters).
+	Fault *FaultDefinition `yaml:"fault"`
+
+	// RevealTimeScaling is the parameter of the WithholdingVoter.
+	RevealTimeScaling float64 `yaml:"revealTimeScaling"`
+
+	// Groups is the parameter of the TimeScalingAttacker.
+	Groups int `yaml:"groups"`
+
+	// GroupDelay is the parameter of the TimeScalingAttacker.
+	GroupDelay Duration `yaml:"groupDelay"`
+
+	// FlipProbability is the parameter of the NoisyHonestVoter.
+	FlipProbability float64 `yaml:"flipProbability"`
+
+	// Lookahead is the parameter of the AdaptiveAttacker.
+	Lookahead int `yaml:"lookahead"`
+
+	// RevealDelay is the parameter of the AttackerCoalition.
+	RevealDelay Duration `yaml:"revealDelay"`
+
+	// MinorityMembers is the number of members of the AttackerCoalition with the MinorityRole.
+	MinorityMembers int `yaml:"minorityMembers"`
+
+	// LowerHashMembers is the number of members of the AttackerCoalition with the LowerHashRole.
+	LowerHashMembers int `yaml:"lowerHashMembers"`
+
+	// SilentMembers is the number of members of the AttackerCoalition with the SilentRole.
+	SilentMembers int `yaml:"silentMembers"`
+}
+
+// setup returns a function that adds the group of Voters to a Network.
+func (v *VoterDefinition) setup(baseDir string) (addVoters func(network *Network), err error) {
+	if _, err = v.voterFactory(); err != nil {
+		return nil, err
+	}
+
+	weights, err := v.Weights.weights(v.Amount, baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := v.Amount
+	if amount == 0 && v.Weights.definesAmount() {
+		amount = len(weights)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%s needs a positive amount: %w", v.Type, ErrInvalidScenario)
+	}
+	if len(weights) != 1 && len(weights) != amount {
+		return nil, fmt.Errorf("%s defines %d weights for %d voters: %w", v.Type, len(weights), amount, ErrInvalidScenario)
+	}
+
+	return func(network *Network) {
+		// every Network gets its own VoterFactory, so Voters that coordinate (e.g. an AttackerCoalition) do not share
+		// their state across runs
+		voterFactory, _ := v.voterFactory()
+
+		if len(weights) == 1 {
+			network.AddVoters(amount, voterFactory, FixedWeight(weights[0]))
+
+			return
+		}
+
+		network.AddVoters(amount, voterFactory, SequentialWeights(weights))
+	}, nil
+}
+
+// voterFactory returns the VoterFactory of the group.
+func (v *VoterDefinition) voterFactory() (voterFactory VoterFactory, err error) {
+	voterTypesMutex.RLock()
+	voterType, exists := voterTypes[v.Type]
+	voterTypesMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown voter type %q (known types are %v): %w", v.Type, VoterTypes(), ErrInvalidScenario)
+	}
+
+	if voterFactory, err = voterType(v); err != nil {
+		return nil, err
+	}
+
+	if v.Fault == nil {
+		return voterFactory, nil
+	}
+
+	if v.Type != "HonestVoter" {
+		return nil, fmt.Errorf("faults are only supported for HonestVoters but not for %s: %w", v.Type, ErrInvalidScenario)
+	}
+
+	return v.Fault.voterFactory()
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region voterTypes ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// VoterType turns a VoterDefinition into the VoterFactory of the Voters of the group.
+type VoterType func(definition *VoterDefinition) (voterFactory VoterFactory, err error)
+
+// voterTypes contains the VoterTypes that can be used in scenario definitions.
+var voterTypes = map[string]VoterType{
+	"HonestVoter":       staticVoterType(NewHonestVoter),
+	"MinorityVoter":     staticVoterType(NewMinorityVoter),
+	"LowerHashVoter":    staticVoterType(NewLowerHashVoter),
+	"SlowMinorityVoter": staticVoterType(NewSlowMinorityVoter),
+	"RandomVoter":       staticVoterType(NewRandomVoter),
+	"StubbornVoter":     staticVoterType(NewStubbornVoter),
+	"WithholdingVoter": func(definition *VoterDefinition) (VoterFactory, error) {
+		return NewWithholdingVoter(definition.RevealTimeScaling), nil
+	},
+	"TimeScalingAttacker": func(definition *VoterDefinition) (VoterFactory, error) {
+		if definition.Groups <= 0 {
+			return nil, fmt.Errorf("TimeScalingAttacker needs a positive number of groups: %w", ErrInvalidScenario)
+		}
+
+		return NewTimeScalingAttacker(definition.Groups, time.Duration(definition.GroupDelay)), nil
+	},
+	"NoisyHonestVoter": func(definition *VoterDefinition) (VoterFactory, error) {
+		if definition.FlipProbability < 0 || definition.FlipProbability > 1 {
+			return nil, fmt.Errorf("flip probability of %f must be in [0, 1]: %w", definition.FlipProbability, ErrInvalidScenario)
+		}
+
+		return NewNoisyHonestVoter(definition.FlipProbability), nil
+	},
+	"AdaptiveAttacker": func(definition *VoterDefinition) (VoterFactory, error) {
+		if definition.Lookahead <= 0 {
+			return nil, fmt.Errorf("AdaptiveAttacker needs a positive lookahead: %w", ErrInvalidScenario)
+		}
+
+		return NewAdaptiveAttacker(definition.Lookahead), nil
+	},
+	"AttackerCoalition": coalitionVoterType,
+}
+
+// voterTypesMutex is the mutex that is used to synchronize access to the voterTypes.
+var voterTypesMutex sync.RWMutex
+
+// RegisterVoterType makes the given VoterType available to scenario definitions under the given name.
+func RegisterVoterType(name string, voterType VoterType) {
+	voterTypesMutex.Lock()
+	defer voterTypesMutex.Unlock()
+
+	voterTypes[name] = voterType
+}
+
+// VoterTypes returns the names of all registered VoterTypes in alphabetical order.
+func VoterTypes() (names []string) {
+	voterTypesMutex.RLock()
+	defer voterTypesMutex.RUnlock()
+
+	for name := range voterTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// coalitionVoterType is the VoterType of the members of an AttackerCoalition. The members of the group get their roles
+// in the order minority, lower hash and silent.
+func coalitionVoterType(definition *VoterDefinition) (VoterFactory, error) {
+	var memberRoles []CoalitionRole
+	for role, members := range []int{definition.MinorityMembers, definition.LowerHashMembers, definition.SilentMembers} {
+		if members < 0 {
+			return nil, fmt.Errorf("AttackerCoalition needs a non-negative number of %s members: %w", CoalitionRole(role), ErrInvalidScenario)
+		}
+
+		for i := 0; i < members; i++ {
+			memberRoles = append(memberRoles, CoalitionRole(role))
+		}
+	}
+	if len(memberRoles) != definition.Amount {
+		return nil, fmt.Errorf("AttackerCoalition defines %d members for an amount of %d: %w", len(memberRoles), definition.Amount, ErrInvalidScenario)
+	}
+
+	var coalition *AttackerCoalition
+
+	return func(network *Network) Voter {
+		if coalition == nil {
+			coalition = NewAttackerCoalition(network, time.Duration(definition.RevealDelay))
+		}
+
+		return coalition.MemberFactory(memberRoles[len(coalition.Members())])(network)
+	}, nil
+}
+
+// staticVoterType returns a VoterType for Voters that do not have any parameters.
+func staticVoterType(voterFactory VoterFactory) VoterType {
+	return func(*VoterDefinition) (VoterFactory, error) {
+		return voterFactory, nil
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region WeightDefinition /////////////////////////////////////////////////////////////////////////////////////////////
+
+// WeightDefinition describes how the weight is distributed between the Voters of a group.
+type WeightDefinition struct {
+	// Type is one of fixed, uniform, zipf, pareto, exponential, sequential or csv.
+	Type string `yaml:"type"`
+
+	// Weight is the weight of every Voter (fixed).
+	Weight float64 `yaml:"weight"`
+
+	// Total is the weight that is split between the Voters of the group (uniform, zipf, pareto, exponential, csv).
+	Total float64 `yaml:"total"`
+
+	// S is the exponent of the zipf distribution.
+	S float64 `yaml:"s"`
+
+	// Alpha is the shape of the pareto distribution.
+	Alpha float64 `yaml:"alpha"`
+
+	// Lambda is the rate of the exponential distribution.
+	Lambda float64 `yaml:"lambda"`
+
+	// Values are the weights of the Voters (sequential).
+	Values []float64 `yaml:"values"`
+
+	// File is the path of a CSV file with the weights of the Voters (csv).
+	File string `yaml:"file"`
+}
+
+// weights returns the weights of the Voters of the group (a single weight if all Voters have the same weight).
+func (w *WeightDefinition) weights(amount int, baseDir string) (weights []float64, err error) {
+	switch w.Type {
+	case "fixed":
+		return []float64{w.Weight}, nil
+	case "uniform":
+		return generateWeightsOf(amount, UniformWeights(amount, w.Total)), nil
+	case "zipf":
+		return generateWeightsOf(amount, ZipfWeights(amount, w.S, w.Total)), nil
+	case "pareto":
+		return generateWeightsOf(amount, ParetoWeights(amount, w.Alpha, w.Total)), nil
+	case "exponential":
+		return generateWeightsOf(amount, ExponentialWeights(amount, w.Lambda, w.Total)), nil
+	case "sequential":
+		return w.Values, nil
+	case "csv":
+		path := w.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+
+		file, openErr := os.Open(path)
+		if openErr != nil {
+			return nil, fmt.Errorf("failed to open weight file: %s: %w", openErr, ErrInvalidScenario)
+		}
+		defer file.Close()
+
+		weightGenerator, csvAmount, csvErr := CSVWeights(file, w.Total)
+		if csvErr != nil {
+			return nil, fmt.Errorf("%s: %w", csvErr, ErrInvalidScenario)
+		}
+
+		return generateWeightsOf(csvAmount, weightGenerator), nil
+	default:
+		return nil, fmt.Errorf("unknown weight type %q: %w", w.Type, ErrInvalidScenario)
+	}
+}
+
+// definesAmount returns true if the WeightDefinition determines the number of Voters of the group by itself.
+func (w *WeightDefinition) definesAmount() bool {
+	return w.Type == "sequential" || w.Type == "csv"
+}
+
+// generateWeightsOf returns the weights that the given WeightGenerator assigns to the given amount of Voters.
+func generateWeightsOf(amount int, weightGenerator WeightGenerator) (weights []float64) {
+	if amount <= 0 {
+		return nil
+	}
+
+	weights = make([]float64, amount)
+	for i := range weights {
+		weights[i] = weightGenerator(0)
+	}
+
+	return weights
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region FaultDefinition //////////////////////////////////////////////////////////////////////////////////////////////
+
+// FaultDefinition describes the FaultModel of a group of HonestVoters.
+type FaultDefinition struct {
+	// Type is either crash or sleepy.
+	Type string `yaml:"type"`
+
+	// After is the time after which the Voters crash (crash).
+	After Duration `yaml:"after"`
+
+	// MeanOnlineTime is the mean duration of the online periods (sleepy).
+	MeanOnlineTime Duration `yaml:"meanOnlineTime"`
+
+	// MeanOfflineTime is the mean duration of the offline periods (sleepy).
+	MeanOfflineTime Duration `yaml:"meanOfflineTime"`
+}
+
+// voterFactory returns the VoterFactory for faulty HonestVoters.
+func (f *FaultDefinition) voterFactory() (voterFactory VoterFactory, err error) {
+	switch f.Type {
+	case "crash":
+		return NewCrashFaultyVoter(time.Duration(f.After)), nil
+	case "sleepy":
+		if f.MeanOnlineTime <= 0 || f.MeanOfflineTime <= 0 {
+			return nil, fmt.Errorf("sleepy faults need positive mean online and offline times: %w", ErrInvalidScenario)
+		}
+
+		return NewSleepyVoter(time.Duration(f.MeanOnlineTime), time.Duration(f.MeanOfflineTime)), nil
+	default:
+		return nil, fmt.Errorf("unknown fault type %q: %w", f.Type, ErrInvalidScenario)
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TimeScalingDefinition ////////////////////////////////////////////////////////////////////////////////////////
+
+// TimeScalingDefinition describes the TimeScaling of the Network.
+type TimeScalingDefinition struct {
+	// Type is one of linear, exponential, step, logistic or sqrt.
+	Type string `yaml:"type"`
+
+	// Rate is the parameter of the exponential TimeScaling.
+	Rate float64 `yaml:"rate"`
+
+	// StepAt is the parameter of the step TimeScaling.
+	StepAt float64 `yaml:"stepAt"`
+
+	// Steepness is the parameter of the logistic TimeScaling.
+	Steepness float64 `yaml:"steepness"`
+
+	// Midpoint is the parameter of the logistic TimeScaling.
+	Midpoint float64 `yaml:"midpoint"`
+}
+
+// TimeScaling returns the described TimeScaling.
+func (t *TimeScalingDefinition) TimeScaling() (timeScaling TimeScaling, err error) {
+	switch t.Type {
+	case "linear":
+		return LinearTimeScaling(), nil
+	case "exponential":
+		return ExponentialTimeScaling(t.Rate), nil
+	case "step":
+		return StepTimeScaling(t.StepAt), nil
+	case "logistic":
+		if t.Steepness <= 0 {
+			return nil, fmt.Errorf("logistic time scaling needs a positive steepness: %w", ErrInvalidScenario)
+		}
+
+		return LogisticTimeScaling(t.Steepness, t.Midpoint), nil
+	case "sqrt":
+		return SqrtTimeScaling(), nil
+	default:
+		return nil, fmt.Errorf("unknown time scaling type %q: %w", t.Type, ErrInvalidScenario)
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region SuccessCriteria //////////////////////////////////////////////////////////////////////////////////////////////
+
+// SuccessCriteria define when a run of a scenario is considered successful.
+type SuccessCriteria struct {
+	// Resolved defines whether the conflict is expected to be resolved (defaults to true).
+	Resolved *bool `yaml:"resolved"`
+
+	// Within is the maximum resolution time of a successful run (0 only requires the conflict to be resolved before the
+	// timeout).
+	Within Duration `yaml:"within"`
+
+	// MinProbability is the minimum lower bound of the confidence interval of the share of successful runs of a Batch
+	// (0 requires every run to be successful).
+	MinProbability float64 `yaml:"minProbability"`
+}
+
+// Evaluate returns true if a single run with the given outcome is successful.
+func (s SuccessCriteria) Evaluate(resolved bool, resolutionTime time.Duration) bool {
+	if !s.expectsResolution() {
+		return !resolved
+	}
+
+	return resolved && resolutionTime <= s.maxResolutionTime()
+}
+
+// EvaluateBatch returns true if enough runs of the given BatchResult are successful.
+func (s SuccessCriteria) EvaluateBatch(result *BatchResult) bool {
+	successfulRuns := result.Runs - result.ResolvedRuns
+	if s.expectsResolution() {
+		successfulRuns = 0
+		for _, resolutionTime := range result.ResolutionTimes {
+			if resolutionTime <= s.maxResolutionTime() {
+				successfulRuns++
+			}
+		}
+	}
+
+	if s.MinProbability == 0 {
+		return successfulRuns == result.Runs
+	}
+
+	_, lowerBound, _ := result.probability(successfulRuns)
+
+	return lowerBound >= s.MinProbability
+}
+
+// expectsResolution returns true if the conflict is expected to be resolved.
+func (s SuccessCriteria) expectsResolution() bool {
+	return s.Resolved == nil || *s.Resolved
+}
+
+// maxResolutionTime returns the maximum resolution time of a successful run.
+func (s SuccessCriteria) maxResolutionTime() time.Duration {
+	if s.Within == 0 {
+		return math.MaxInt64
+	}
+
+	return time.Duration(s.Within)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region Duration /////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Duration is a time.Duration that is written as a string (e.g. "1.5s") in scenario definitions.
+type Duration time.Duration
+
+// UnmarshalYAML parses the Duration from its string representation.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) (err error) {
+	var durationString string
+	if err = value.Decode(&durationString); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(durationString)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*d = Duration(duration)
+
+	return nil
+}
+
+// MarshalYAML returns the string representation of the Duration.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,178 @@
+package metastabilitybreaker
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadScenario(t *testing.T) {
+	paths, err := filepath.Glob("scenarios/*")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		scenario, err := LoadScenario(path)
+		require.NoError(t, err, path)
+
+		setup, err := scenario.Setup()
+		require.NoError(t, err, path)
+
+		result, err := (&Batch{
+			Scenario:  setup,
+			Runs:      100,
+			Seed:      1,
+			BranchIDs: scenario.BranchIDs(),
+			Timeout:   time.Duration(scenario.Timeout),
+		}).Run()
+		require.NoError(t, err, path)
+		assert.True(t, scenario.Success.EvaluateBatch(result), "%s does not meet its success criteria:\n%s", path, result)
+	}
+
+	// a stronger MinorityVoter only fails the criteria if the metastability breaker is disabled
+	for threshold, expectedSuccess := range map[string]bool{"0s": false, "1s": true} {
+		scenario, err := ParseScenario([]byte(`
+metastabilityBreakingThreshold: ` + threshold + `
+latency: 200ms
+weightDeviation: 0.05
+voters:
+  - {type: HonestVoter, amount: 7, weights: {type: fixed, weight: 0.1}}
+  - {type: MinorityVoter, amount: 1, weights: {type: fixed, weight: 0.3}}
+branches: [1, 2]
+timeout: 60s
+success: {within: 1500ms, minProbability: 0.9}
+`))
+		require.NoError(t, err)
+
+		setup, err := scenario.Setup()
+		require.NoError(t, err)
+
+		result, err := (&Batch{
+			Scenario:  setup,
+			Runs:      100,
+			Seed:      1,
+			BranchIDs: scenario.BranchIDs(),
+			Timeout:   time.Duration(scenario.Timeout),
+		}).Run()
+		require.NoError(t, err)
+		assert.Equal(t, expectedSuccess, scenario.Success.EvaluateBatch(result), "unexpected outcome with a threshold of %s:\n%s", threshold, result)
+	}
+}
+
+func TestScenario_NewNetwork(t *testing.T) {
+	scenario, err := ParseScenario([]byte(`
+metastabilityBreakingThreshold: 2s
+breakerCeiling: 0.5
+timeScaling: {type: step, stepAt: 0.5}
+latency: 100ms
+voters:
+  - {type: HonestVoter, amount: 3, weights: {type: sequential, values: [0.3, 0.2, 0.1]}}
+  - {type: HonestVoter, amount: 1, weights: {type: fixed, weight: 0.1}, fault: {type: crash, after: 1s}}
+  - {type: NoisyHonestVoter, amount: 1, flipProbability: 0.1, weights: {type: fixed, weight: 0.3}}
+branches: [1, 2]
+timeout: 10s
+`))
+	require.NoError(t, err)
+
+	network, err := scenario.NewNetwork()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2*time.Second, network.MetastabilityBreakingThreshold)
+	assert.Equal(t, DefaultConfirmationThreshold, network.ConfirmationThreshold)
+	assert.Equal(t, 0.5, network.BreakerCeiling)
+	assert.Equal(t, 100*time.Millisecond, network.PerceptionNoise.MaxUpdateDelay)
+	assert.Equal(t, []BranchID{1, 2}, scenario.BranchIDs())
+
+	voters := network.Voters()
+	require.Len(t, voters, 5)
+	assert.Equal(t, 0.3, network.WeightDistribution.Weight(voters[0].ID()))
+	assert.Equal(t, 0.1, network.WeightDistribution.Weight(voters[2].ID()))
+	assert.Equal(t, "NoisyHonestVoter", voters[4].Type())
+	assert.NotNil(t, voters[3].(*HonestVoter).faultModel)
+}
+
+func TestScenario_AttackerCoalition(t *testing.T) {
+	scenario, err := ParseScenario([]byte(`
+voters:
+  - {type: HonestVoter, amount: 8, weights: {type: fixed, weight: 0.1}}
+  - {type: AttackerCoalition, amount: 4, minorityMembers: 2, lowerHashMembers: 1, silentMembers: 1, revealDelay: 500ms, weights: {type: uniform, total: 0.2}}
+branches: [1, 2]
+timeout: 10s
+`))
+	require.NoError(t, err)
+
+	var coalitions []*AttackerCoalition
+	for i := 0; i < 2; i++ {
+		network, err := scenario.NewNetwork()
+		require.NoError(t, err)
+
+		voters := network.Voters()
+		require.Len(t, voters, 12)
+		for j, expectedType := range []string{"CoalitionMinorityVoter", "CoalitionMinorityVoter", "CoalitionLowerHashVoter", "CoalitionSilentVoter"} {
+			assert.Equal(t, expectedType, voters[8+j].Type())
+		}
+		assert.InDelta(t, 0.2, network.WeightDistributionStats().AttackerWeight, 1e-9)
+
+		coalition := voters[8].(*CoalitionMember).coalition
+		assert.Len(t, coalition.Members(), 4)
+		assert.Equal(t, 500*time.Millisecond, coalition.revealDelay)
+		coalitions = append(coalitions, coalition)
+	}
+
+	// every Network gets its own coalition
+	assert.NotSame(t, coalitions[0], coalitions[1])
+}
+
+func TestParseScenario_Invalid(t *testing.T) {
+	for name, definition := range map[string]string{
+		"UnknownField":     "voterz: []",
+		"InvalidDuration":  "timeout: forever",
+		"UnknownVoterType": "{voters: [{type: EvilVoter, amount: 1, weights: {type: fixed, weight: 1}}], branches: [1], timeout: 1s}",
+		"UnknownWeights":   "{voters: [{type: HonestVoter, amount: 1, weights: {type: magic}}], branches: [1], timeout: 1s}",
+		"FaultyAttacker":   "{voters: [{type: MinorityVoter, amount: 1, weights: {type: fixed, weight: 1}, fault: {type: crash, after: 1s}}], branches: [1], timeout: 1s}",
+		"InvalidWeights":   "{voters: [{type: HonestVoter, amount: 2, weights: {type: fixed, weight: 0.3}}], branches: [1], timeout: 1s}",
+		"InvalidCeiling":   "{breakerCeiling: 2, voters: [{type: HonestVoter, amount: 1, weights: {type: fixed, weight: 1}}], branches: [1], timeout: 1s}",
+		"NoBranches":       "{voters: [{type: HonestVoter, amount: 1, weights: {type: fixed, weight: 1}}], timeout: 1s}",
+		"MissingAmount":    "{voters: [{type: HonestVoter, weights: {type: fixed, weight: 1}}], branches: [1], timeout: 1s}",
+		"CoalitionAmount":  "{voters: [{type: AttackerCoalition, amount: 3, minorityMembers: 2, weights: {type: fixed, weight: 0.1}}], branches: [1], timeout: 1s}",
+	} {
+		scenario, err := ParseScenario([]byte(definition))
+		if err == nil {
+			_, err = scenario.Setup()
+		}
+
+		assert.ErrorIs(t, err, ErrInvalidScenario, name)
+	}
+}
+
+func TestRegisterVoterType(t *testing.T) {
+	RegisterVoterType("ScenarioTestVoter", staticVoterType(NewStubbornVoter))
+	assert.Contains(t, VoterTypes(), "ScenarioTestVoter")
+
+	scenario, err := ParseScenario([]byte("{voters: [{type: ScenarioTestVoter, amount: 1, weights: {type: fixed, weight: 1}}], branches: [1], timeout: 1s}"))
+	require.NoError(t, err)
+
+	network, err := scenario.NewNetwork()
+	require.NoError(t, err)
+	assert.Equal(t, "StubbornVoter", network.Voters()[0].Type())
+}
+
+func TestSuccessCriteria(t *testing.T) {
+	successCriteria := SuccessCriteria{Within: Duration(2 * time.Second)}
+	assert.True(t, successCriteria.Evaluate(true, 2*time.Second))
+	assert.False(t, successCriteria.Evaluate(true, 3*time.Second))
+	assert.False(t, successCriteria.Evaluate(false, 0))
+
+	resolved := false
+	successCriteria = SuccessCriteria{Resolved: &resolved}
+	assert.True(t, successCriteria.Evaluate(false, 0))
+	assert.False(t, successCriteria.Evaluate(true, time.Second))
+
+	result := &BatchResult{Runs: 100, ResolvedRuns: 1, ConfidenceLevel: 0.95, ResolutionTimes: []time.Duration{time.Second}}
+	assert.False(t, successCriteria.EvaluateBatch(result))
+	successCriteria.MinProbability = 0.9
+	assert.True(t, successCriteria.EvaluateBatch(result))
+}
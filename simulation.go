@@ -0,0 +1,178 @@
+package metastabilitybreaker
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/hive.go/events"
+)
+
+// simulationStartTime is the time at which the SimulatedClocks of the simulations start.
+var simulationStartTime = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// region simulate /////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// simulate resolves the conflict between the given Branches on the given Network and returns the outcome of the run.
+func simulate(network *Network, timeout time.Duration, branchIDs ...BranchID) (outcome *runOutcome, err error) {
+	defer network.Shutdown()
+
+	opinionFlipCounter := newOpinionFlipCounter(network)
+	network.VoteReceived.Attach(events.NewClosure(opinionFlipCounter.VoteReceived))
+
+	outcome = &runOutcome{}
+	if outcome.resolved, outcome.resolutionTime, err = network.Simulate(timeout, branchIDs...); err != nil {
+		return nil, err
+	}
+	_, outcome.confirmed = network.ConfirmedBranch()
+	outcome.opinionFlips = opinionFlipCounter.OpinionFlips()
+
+	return outcome, nil
+}
+
+// runInParallel executes the given job for every index in [0, jobs) with the given parallelism (0 defaults to the
+// number of CPUs) and returns the first error that occurred.
+func runInParallel(jobs, parallelism int, job func(index int) error) (err error) {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				if jobErr := job(index); jobErr != nil {
+					errOnce.Do(func() { err = jobErr })
+				}
+			}
+		}()
+	}
+
+	for index := 0; index < jobs; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return err
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region runOutcome ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runOutcome is the outcome of a single simulation run.
+type runOutcome struct {
+	resolved       bool
+	resolutionTime time.Duration
+	confirmed      bool
+	opinionFlips   int
+}
+
+// sortedResolutionTimes returns the resolution times of the resolved runs in ascending order.
+func sortedResolutionTimes(outcomes []*runOutcome) (resolutionTimes []time.Duration) {
+	for _, outcome := range outcomes {
+		if outcome.resolved {
+			resolutionTimes = append(resolutionTimes, outcome.resolutionTime)
+		}
+	}
+	sort.Slice(resolutionTimes, func(i, j int) bool { return resolutionTimes[i] < resolutionTimes[j] })
+
+	return resolutionTimes
+}
+
+// confirmationProbability returns the share of the given runs that ended with a confirmed Branch.
+func confirmationProbability(outcomes []*runOutcome) float64 {
+	if len(outcomes) == 0 {
+		return 0
+	}
+
+	var confirmedRuns int
+	for _, outcome := range outcomes {
+		if outcome.confirmed {
+			confirmedRuns++
+		}
+	}
+
+	return float64(confirmedRuns) / float64(len(outcomes))
+}
+
+// meanOpinionFlips returns the mean number of opinion flips of the given runs.
+func meanOpinionFlips(outcomes []*runOutcome) float64 {
+	if len(outcomes) == 0 {
+		return 0
+	}
+
+	var opinionFlips int
+	for _, outcome := range outcomes {
+		opinionFlips += outcome.opinionFlips
+	}
+
+	return float64(opinionFlips) / float64(len(outcomes))
+}
+
+// percentile returns the given percentile (nearest rank) of the given sorted durations (0 if there are none).
+func percentile(sortedDurations []time.Duration, p float64) time.Duration {
+	if len(sortedDurations) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p*float64(len(sortedDurations)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sortedDurations[rank]
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region opinionFlipCounter ///////////////////////////////////////////////////////////////////////////////////////////
+
+// opinionFlipCounter counts how often the HonestVoters of a Network change their opinion.
+type opinionFlipCounter struct {
+	network        *Network
+	lastStatements map[VoterID]BranchID
+	opinionFlips   int
+	mutex          sync.Mutex
+}
+
+// newOpinionFlipCounter returns a new opinionFlipCounter for the given Network.
+func newOpinionFlipCounter(network *Network) *opinionFlipCounter {
+	return &opinionFlipCounter{
+		network:        network,
+		lastStatements: make(map[VoterID]BranchID),
+	}
+}
+
+// VoteReceived counts the Vote as a flip if its issuer is an HonestVoter that voted for a different Branch before.
+func (o *opinionFlipCounter) VoteReceived(vote *Vote) {
+	if issuer, exists := o.network.Voter(vote.Issuer); !exists || issuer.Type() != "HonestVoter" {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if lastStatement, exists := o.lastStatements[vote.Issuer]; exists && lastStatement != vote.BranchID {
+		o.opinionFlips++
+	}
+	o.lastStatements[vote.Issuer] = vote.BranchID
+}
+
+// OpinionFlips returns the number of counted opinion flips.
+func (o *opinionFlipCounter) OpinionFlips() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.opinionFlips
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,16 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	assert.Equal(t, time.Duration(5), percentile(durations, 0.5))
+	assert.Equal(t, time.Duration(10), percentile(durations, 0.95))
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.5))
+}
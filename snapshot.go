@@ -0,0 +1,248 @@
+package metastabilitybreaker
+
+import (
+	"time"
+
+	"github.com/iotaledger/hive.go/types"
+)
+
+// region Snapshot /////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// maxSnapshotDepth defines the number of consecutive forks after which a Snapshot is flattened again, so that the
+// lookups in long chains of forks stay cheap.
+const maxSnapshotDepth = 16
+
+// Snapshot is an immutable copy of the perception of a Voter (the known Branches and the statements of the issuers).
+// Votes can be applied to a Snapshot to evaluate "what-if" scenarios without affecting the live state of the Voter.
+type Snapshot struct {
+	branches        *BranchSnapshot
+	approvalWeights *ApprovalWeightSnapshot
+}
+
+// NewSnapshot returns a Snapshot of the current perception of the given Voter.
+func NewSnapshot(voter Voter) *Snapshot {
+	return &Snapshot{
+		branches:        voter.BranchManager().Snapshot(),
+		approvalWeights: voter.ApprovalWeightManager().Snapshot(),
+	}
+}
+
+// Branches returns the BranchSnapshot that contains the Branches of the Snapshot.
+func (s *Snapshot) Branches() *BranchSnapshot {
+	return s.branches
+}
+
+// ApprovalWeights returns the ApprovalWeightSnapshot that contains the statements of the Snapshot.
+func (s *Snapshot) ApprovalWeights() *ApprovalWeightSnapshot {
+	return s.approvalWeights
+}
+
+// BranchIDs returns the identifiers of all Branches that are known to the Snapshot.
+func (s *Snapshot) BranchIDs() BranchIDs {
+	return s.branches.BranchIDs()
+}
+
+// SolidificationTime returns the time at which the given Branch was first seen.
+func (s *Snapshot) SolidificationTime(branchID BranchID) time.Time {
+	return s.branches.Metadata(branchID).SolidificationTime
+}
+
+// Weight returns the approval weight of the given Branch.
+func (s *Snapshot) Weight(branchID BranchID) float64 {
+	return s.approvalWeights.Weight(branchID)
+}
+
+// LastStatement returns the Branch that the given issuer voted for most recently.
+func (s *Snapshot) LastStatement(issuer VoterID) (branchID BranchID, exists bool) {
+	return s.approvalWeights.LastStatement(issuer)
+}
+
+// WithVote returns a fork of the Snapshot that additionally contains the given Vote. Branches that are not known yet
+// are registered with the given solidification time. It returns the Snapshot itself if the Vote changes nothing.
+func (s *Snapshot) WithVote(vote *Vote, solidificationTime time.Time) *Snapshot {
+	branches := s.branches.WithBranch(vote.BranchID, solidificationTime)
+	approvalWeights := s.approvalWeights.WithVote(vote)
+	if branches == s.branches && approvalWeights == s.approvalWeights {
+		return s
+	}
+
+	return &Snapshot{
+		branches:        branches,
+		approvalWeights: approvalWeights,
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region BranchSnapshot ///////////////////////////////////////////////////////////////////////////////////////////////
+
+// BranchSnapshot is an immutable copy of the Branches that are known to a BranchManager. A fork only stores the Branch
+// that it adds to its parent, so forking is cheap.
+type BranchSnapshot struct {
+	parent       *BranchSnapshot
+	metadataByID map[BranchID]BranchMetadata
+	depth        int
+}
+
+// BranchIDs returns the identifiers of all Branches that are known to the BranchSnapshot.
+func (b *BranchSnapshot) BranchIDs() (branchIDs BranchIDs) {
+	branchIDs = make(BranchIDs)
+	for layer := b; layer != nil; layer = layer.parent {
+		for branchID := range layer.metadataByID {
+			branchIDs[branchID] = types.Void
+		}
+	}
+
+	return branchIDs
+}
+
+// Metadata returns a copy of the metadata of the given Branch (nil if the Branch is unknown).
+func (b *BranchSnapshot) Metadata(branchID BranchID) *BranchMetadata {
+	for layer := b; layer != nil; layer = layer.parent {
+		if metadata, exists := layer.metadataByID[branchID]; exists {
+			return &metadata
+		}
+	}
+
+	return nil
+}
+
+// WithBranch returns a fork of the BranchSnapshot that contains the given Branch. It returns the BranchSnapshot itself
+// if the Branch is known already.
+func (b *BranchSnapshot) WithBranch(branchID BranchID, solidificationTime time.Time) *BranchSnapshot {
+	if b.Metadata(branchID) != nil {
+		return b
+	}
+
+	forkedSnapshot := &BranchSnapshot{
+		parent: b,
+		metadataByID: map[BranchID]BranchMetadata{
+			branchID: {SolidificationTime: solidificationTime},
+		},
+		depth: b.depth + 1,
+	}
+	if forkedSnapshot.depth > maxSnapshotDepth {
+		return forkedSnapshot.flatten()
+	}
+
+	return forkedSnapshot
+}
+
+// flatten returns a BranchSnapshot without a parent that contains the same Branches.
+func (b *BranchSnapshot) flatten() *BranchSnapshot {
+	metadataByID := make(map[BranchID]BranchMetadata)
+	for branchID := range b.BranchIDs() {
+		metadataByID[branchID] = *b.Metadata(branchID)
+	}
+
+	return &BranchSnapshot{
+		metadataByID: metadataByID,
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region ApprovalWeightSnapshot ///////////////////////////////////////////////////////////////////////////////////////
+
+// ApprovalWeightSnapshot is an immutable copy of the statements and weights of an ApprovalWeightManager (including the
+// weights of the issuers at the time the snapshot was taken). A fork only stores the statement and the weights that it
+// changes, so forking is cheap.
+type ApprovalWeightSnapshot struct {
+	parent         *ApprovalWeightSnapshot
+	voterWeights   map[VoterID]float64
+	weights        map[BranchID]float64
+	lastStatements map[VoterID]BranchID
+	depth          int
+}
+
+// Weight returns the approval weight of the given Branch.
+func (a *ApprovalWeightSnapshot) Weight(branchID BranchID) float64 {
+	for layer := a; layer != nil; layer = layer.parent {
+		if weight, exists := layer.weights[branchID]; exists {
+			return weight
+		}
+	}
+
+	return 0
+}
+
+// Weights returns a copy of the approval weights of all Branches.
+func (a *ApprovalWeightSnapshot) Weights() (weights map[BranchID]float64) {
+	weights = make(map[BranchID]float64)
+	for layer := a; layer != nil; layer = layer.parent {
+		for branchID, weight := range layer.weights {
+			if _, exists := weights[branchID]; !exists {
+				weights[branchID] = weight
+			}
+		}
+	}
+
+	return weights
+}
+
+// LastStatement returns the Branch that the given issuer voted for most recently.
+func (a *ApprovalWeightSnapshot) LastStatement(issuer VoterID) (branchID BranchID, exists bool) {
+	for layer := a; layer != nil; layer = layer.parent {
+		if branchID, exists = layer.lastStatements[issuer]; exists {
+			return branchID, true
+		}
+	}
+
+	return UndefinedBranchID, false
+}
+
+// LastStatements returns a copy of the last statements of all issuers.
+func (a *ApprovalWeightSnapshot) LastStatements() (lastStatements map[VoterID]BranchID) {
+	lastStatements = make(map[VoterID]BranchID)
+	for layer := a; layer != nil; layer = layer.parent {
+		for issuer, branchID := range layer.lastStatements {
+			if _, exists := lastStatements[issuer]; !exists {
+				lastStatements[issuer] = branchID
+			}
+		}
+	}
+
+	return lastStatements
+}
+
+// WithVote returns a fork of the ApprovalWeightSnapshot that additionally contains the given Vote. It returns the
+// ApprovalWeightSnapshot itself if the Vote does not change the statement of its issuer.
+func (a *ApprovalWeightSnapshot) WithVote(vote *Vote) *ApprovalWeightSnapshot {
+	lastBranchID, statementExists := a.LastStatement(vote.Issuer)
+	if statementExists && lastBranchID == vote.BranchID {
+		return a
+	}
+
+	forkedSnapshot := &ApprovalWeightSnapshot{
+		parent:       a,
+		voterWeights: a.voterWeights,
+		weights:      make(map[BranchID]float64, 2),
+		lastStatements: map[VoterID]BranchID{
+			vote.Issuer: vote.BranchID,
+		},
+		depth: a.depth + 1,
+	}
+
+	issuerWeight := a.voterWeights[vote.Issuer]
+	if statementExists {
+		forkedSnapshot.weights[lastBranchID] = a.Weight(lastBranchID) - issuerWeight
+	}
+	forkedSnapshot.weights[vote.BranchID] = a.Weight(vote.BranchID) + issuerWeight
+
+	if forkedSnapshot.depth > maxSnapshotDepth {
+		return forkedSnapshot.flatten()
+	}
+
+	return forkedSnapshot
+}
+
+// flatten returns an ApprovalWeightSnapshot without a parent that contains the same statements and weights.
+func (a *ApprovalWeightSnapshot) flatten() *ApprovalWeightSnapshot {
+	return &ApprovalWeightSnapshot{
+		voterWeights:   a.voterWeights,
+		weights:        a.Weights(),
+		lastStatements: a.LastStatements(),
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,73 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_WithVote(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.AddVoters(2, NewHonestVoter, FixedWeight(0.5))
+	voters := network.Voters()
+	voters[0].ApprovalWeightManager().ProcessVote(&Vote{Issuer: voters[0].ID(), BranchID: NewBranchID(1)})
+
+	snapshot := NewSnapshot(voters[0])
+	fork := snapshot.WithVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(2)}, time.Now())
+	secondFork := fork.WithVote(&Vote{Issuer: voters[0].ID(), BranchID: NewBranchID(2)}, time.Now())
+
+	assert.Equal(t, 1.0, secondFork.Weight(NewBranchID(2)))
+	assert.Equal(t, float64(0), secondFork.Weight(NewBranchID(1)))
+	assert.Equal(t, 0.5, fork.Weight(NewBranchID(2)))
+	assert.Equal(t, 0.5, fork.Weight(NewBranchID(1)))
+	assert.Equal(t, 0.5, snapshot.Weight(NewBranchID(1)))
+	assert.Equal(t, float64(0), snapshot.Weight(NewBranchID(2)))
+	assert.Len(t, snapshot.BranchIDs(), 1)
+	assert.Len(t, fork.BranchIDs(), 2)
+	assert.Same(t, fork, fork.WithVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(2)}, time.Now()))
+
+	assert.Same(t, snapshot.ApprovalWeights(), fork.ApprovalWeights().parent, "forks should only store their changes")
+
+	assert.Equal(t, float64(0), voters[0].ApprovalWeightManager().Weight(NewBranchID(2)))
+	assert.Nil(t, voters[0].BranchManager().Metadata(NewBranchID(2)))
+	assert.Len(t, voters[0].ApprovalWeightManager().LastStatements(), 1)
+
+	network.WeightDistribution.SetWeight(voters[1].ID(), 0.25)
+	assert.Equal(t, 0.5, snapshot.WithVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(2)}, time.Now()).Weight(NewBranchID(2)), "snapshot should keep the weights at the time it was taken")
+}
+
+func TestSnapshot_LongForkChain(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.AddVoters(2, NewHonestVoter, FixedWeight(0.5))
+	voters := network.Voters()
+
+	snapshot := NewSnapshot(voters[0])
+	for i := 0; i < 5*maxSnapshotDepth; i++ {
+		snapshot = snapshot.WithVote(&Vote{Issuer: voters[i%2].ID(), BranchID: NewBranchID(i%3 + 1)}, time.Now())
+		assert.LessOrEqual(t, snapshot.ApprovalWeights().depth, maxSnapshotDepth)
+	}
+
+	assert.Len(t, snapshot.ApprovalWeights().LastStatements(), 2)
+	assert.Len(t, snapshot.BranchIDs(), 3)
+	assert.InDelta(t, 0.5, snapshot.Weight(NewBranchID(1)), 1e-9)
+	assert.InDelta(t, 0.5, snapshot.Weight(NewBranchID(2)), 1e-9)
+	assert.InDelta(t, 0, snapshot.Weight(NewBranchID(3)), 1e-9)
+}
+
+func TestConsensus_FavoredBranchAt(t *testing.T) {
+	network := NewNetwork(1 * time.Second)
+	network.AddVoters(10, NewHonestVoter, FixedWeight(0.1))
+	voters := network.Voters()
+
+	now := time.Now()
+	snapshot := NewSnapshot(voters[0]).
+		WithVote(&Vote{Issuer: voters[0].ID(), BranchID: NewBranchID(2)}, now).
+		WithVote(&Vote{Issuer: voters[1].ID(), BranchID: NewBranchID(2)}, now).
+		WithVote(&Vote{Issuer: voters[2].ID(), BranchID: NewBranchID(1)}, now)
+
+	consensus := NewConsensus(voters[0])
+	assert.Equal(t, NewBranchID(2), consensus.FavoredBranchAt(snapshot, now), "heavier branch should win without time scaling")
+	assert.Equal(t, NewBranchID(1), consensus.FavoredBranchAt(snapshot, now.Add(time.Second)), "lower hash should win once the breaker is fully active")
+	assert.Equal(t, UndefinedBranchID, consensus.FavoredBranch(), "live state should not be affected")
+}
@@ -0,0 +1,334 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// region Sweep ////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Sweep runs the same scenario across a grid of parameters. Every run uses its own Network with a SimulatedClock and a
+// source of randomness that is seeded with Seed+i (i being the index of the run in the whole Sweep), so the runs are
+// independent of each other, are executed in parallel and can be reproduced.
+type Sweep struct {
+	// Scenario adds the Voters of the scenario to the Network according to the given parameters.
+	Scenario SweepScenario
+
+	// MetastabilityBreakingThresholds are the values of the MetastabilityBreakingThreshold that are swept over.
+	MetastabilityBreakingThresholds []time.Duration
+
+	// AttackerWeights are the weights of the attacker that are swept over.
+	AttackerWeights []float64
+
+	// HonestVoters are the numbers of HonestVoters that are swept over (at least one, as every scenario needs honest
+	// Voters to resolve the conflict).
+	HonestVoters []int
+
+	// ConfirmationThresholds are the values of the ConfirmationThreshold that are swept over (empty uses the
+	// DefaultConfirmationThreshold).
+	ConfirmationThresholds []float64
+
+	// BreakerCeilings are the values of the BreakerCeiling that are swept over (empty uses the DefaultBreakerCeiling).
+	BreakerCeilings []float64
+
+	// Latencies are the maximum delays with which votes are processed (the MaxUpdateDelay of the PerceptionNoise).
+	Latencies []time.Duration
+
+	// WeightDeviations are the maximum deviations of the perceived weights (the MaxWeightDeviation of the
+	// PerceptionNoise).
+	WeightDeviations []float64
+
+	// TimeScalings are the TimeScalings that are swept over (by name, in the order of their names).
+	TimeScalings map[string]TimeScaling
+
+	// Runs is the number of runs per combination of parameters.
+	Runs int
+
+	// Seed is the seed of the first run.
+	Seed int64
+
+	// BranchIDs are the conflicting Branches that the Voters need to agree on.
+	BranchIDs []BranchID
+
+	// Timeout is the simulated time after which a run is considered as not resolved.
+	Timeout time.Duration
+
+	// Parallelism is the number of runs that are executed at the same time (0 defaults to the number of CPUs).
+	Parallelism int
+}
+
+// Run executes the scenario for every combination of parameters and returns the aggregated results in the order of the
+// grid. Optional dimensions without values are swept over their zero value (or over the default of the
+// ConfirmationThreshold and the BreakerCeiling).
+func (s *Sweep) Run() (results SweepResults, err error) {
+	grid := s.grid()
+	if err = s.validate(grid); err != nil {
+		return nil, err
+	}
+
+	runs := s.Runs
+
+	outcomes := make([][]*runOutcome, len(grid))
+	for i := range outcomes {
+		outcomes[i] = make([]*runOutcome, runs)
+	}
+
+	if err = runInParallel(len(grid)*runs, s.Parallelism, func(job int) (runErr error) {
+		outcomes[job/runs][job%runs], runErr = s.run(grid[job/runs], s.Seed+int64(job))
+
+		return runErr
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, parameters := range grid {
+		results = append(results, newSweepResult(parameters, outcomes[i]))
+	}
+
+	return results, nil
+}
+
+// validate checks the configuration of the Sweep and the values of its dimensions before any run is started.
+func (s *Sweep) validate(grid []SweepParameters) error {
+	if s.Scenario == nil {
+		return fmt.Errorf("sweep needs a Scenario: %w", ErrInvalidParameter)
+	}
+	if s.Runs <= 0 {
+		return fmt.Errorf("sweep needs at least one run but has %d: %w", s.Runs, ErrInvalidParameter)
+	}
+	if s.Timeout <= 0 {
+		return fmt.Errorf("Timeout of %s must be positive: %w", s.Timeout, ErrInvalidParameter)
+	}
+
+	if len(s.HonestVoters) == 0 {
+		return fmt.Errorf("sweep needs at least one number of HonestVoters: %w", ErrInvalidParameter)
+	}
+	for _, honestVoters := range s.HonestVoters {
+		if honestVoters <= 0 {
+			return fmt.Errorf("number of HonestVoters of %d must be positive: %w", honestVoters, ErrInvalidParameter)
+		}
+	}
+	for _, attackerWeight := range s.AttackerWeights {
+		if math.IsNaN(attackerWeight) || attackerWeight < 0 || attackerWeight >= 1 {
+			return fmt.Errorf("AttackerWeight of %f must be in [0, 1): %w", attackerWeight, ErrInvalidParameter)
+		}
+	}
+	for _, latency := range s.Latencies {
+		if latency < 0 {
+			return fmt.Errorf("Latency of %s must not be negative: %w", latency, ErrInvalidParameter)
+		}
+	}
+	for _, weightDeviation := range s.WeightDeviations {
+		if math.IsNaN(weightDeviation) || weightDeviation < 0 {
+			return fmt.Errorf("WeightDeviation of %f must not be negative: %w", weightDeviation, ErrInvalidParameter)
+		}
+	}
+	for name, timeScaling := range s.TimeScalings {
+		if timeScaling == nil {
+			return fmt.Errorf("TimeScaling '%s' must not be nil: %w", name, ErrInvalidParameter)
+		}
+	}
+
+	// the breaking threshold, the confirmation threshold and the breaker ceiling are checked by the Network itself
+	for _, parameters := range grid {
+		network := NewNetwork(parameters.MetastabilityBreakingThreshold)
+		network.ConfirmationThreshold = parameters.ConfirmationThreshold
+		network.BreakerCeiling = parameters.BreakerCeiling
+		if err := network.ValidateParameters(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// grid returns all combinations of the swept parameters.
+func (s *Sweep) grid() (grid []SweepParameters) {
+	timeScalings := make([]string, 0, len(s.TimeScalings))
+	for timeScaling := range s.TimeScalings {
+		timeScalings = append(timeScalings, timeScaling)
+	}
+	sort.Strings(timeScalings)
+
+	grid = []SweepParameters{{}}
+	grid = expandGrid(grid, len(timeScalings), func(parameters *SweepParameters, i int) {
+		parameters.TimeScaling = timeScalings[i]
+	})
+	grid = expandGrid(grid, len(s.MetastabilityBreakingThresholds), func(parameters *SweepParameters, i int) {
+		parameters.MetastabilityBreakingThreshold = s.MetastabilityBreakingThresholds[i]
+	})
+	grid = expandGrid(grid, len(s.ConfirmationThresholds), func(parameters *SweepParameters, i int) {
+		parameters.ConfirmationThreshold = s.ConfirmationThresholds[i]
+	})
+	grid = expandGrid(grid, len(s.BreakerCeilings), func(parameters *SweepParameters, i int) {
+		parameters.BreakerCeiling = s.BreakerCeilings[i]
+	})
+	grid = expandGrid(grid, len(s.AttackerWeights), func(parameters *SweepParameters, i int) {
+		parameters.AttackerWeight = s.AttackerWeights[i]
+	})
+	grid = expandGrid(grid, len(s.HonestVoters), func(parameters *SweepParameters, i int) {
+		parameters.HonestVoters = s.HonestVoters[i]
+	})
+	grid = expandGrid(grid, len(s.Latencies), func(parameters *SweepParameters, i int) {
+		parameters.Latency = s.Latencies[i]
+	})
+	grid = expandGrid(grid, len(s.WeightDeviations), func(parameters *SweepParameters, i int) {
+		parameters.WeightDeviation = s.WeightDeviations[i]
+	})
+
+	for i := range grid {
+		if len(s.ConfirmationThresholds) == 0 {
+			grid[i].ConfirmationThreshold = DefaultConfirmationThreshold
+		}
+		if len(s.BreakerCeilings) == 0 {
+			grid[i].BreakerCeiling = DefaultBreakerCeiling
+		}
+	}
+
+	return grid
+}
+
+// expandGrid combines every entry of the given grid with the given amount of values of another dimension (set applies
+// the i-th value to a copy of an entry). A dimension without values leaves the grid unchanged.
+func expandGrid(grid []SweepParameters, values int, set func(parameters *SweepParameters, i int)) (expandedGrid []SweepParameters) {
+	if values == 0 {
+		return grid
+	}
+
+	expandedGrid = make([]SweepParameters, 0, len(grid)*values)
+	for _, parameters := range grid {
+		for i := 0; i < values; i++ {
+			expandedParameters := parameters
+			set(&expandedParameters, i)
+			expandedGrid = append(expandedGrid, expandedParameters)
+		}
+	}
+
+	return expandedGrid
+}
+
+// run executes the scenario once with the given parameters and seed.
+func (s *Sweep) run(parameters SweepParameters, seed int64) (outcome *runOutcome, err error) {
+	network := NewNetwork(parameters.MetastabilityBreakingThreshold)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(seed)
+	network.ConfirmationThreshold = parameters.ConfirmationThreshold
+	network.BreakerCeiling = parameters.BreakerCeiling
+	network.PerceptionNoise.MaxUpdateDelay = parameters.Latency
+	network.PerceptionNoise.MaxWeightDeviation = parameters.WeightDeviation
+	network.TimeScaling = s.TimeScalings[parameters.TimeScaling]
+	s.Scenario(network, parameters)
+
+	return simulate(network, s.Timeout, s.BranchIDs...)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region SweepScenario ////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SweepScenario adds the Voters of a scenario to the Network according to the given parameters.
+type SweepScenario func(network *Network, parameters SweepParameters)
+
+// NewSweepScenario returns a SweepScenario that splits the honest weight equally between the HonestVoters and gives
+// the AttackerWeight to a single Voter that is created by the given factory.
+func NewSweepScenario(attackerFactory VoterFactory) SweepScenario {
+	return func(network *Network, parameters SweepParameters) {
+		network.AddVoters(parameters.HonestVoters, NewHonestVoter, UniformWeights(parameters.HonestVoters, 1-parameters.AttackerWeight))
+		if parameters.AttackerWeight > 0 {
+			network.AddVoters(1, attackerFactory, FixedWeight(parameters.AttackerWeight))
+		}
+	}
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region SweepParameters //////////////////////////////////////////////////////////////////////////////////////////////
+
+// SweepParameters is a single combination of the parameters of a Sweep (the TimeScaling is empty if the Sweep does not
+// sweep over TimeScalings).
+type SweepParameters struct {
+	TimeScaling                    string
+	MetastabilityBreakingThreshold time.Duration
+	ConfirmationThreshold          float64
+	BreakerCeiling                 float64
+	AttackerWeight                 float64
+	HonestVoters                   int
+	Latency                        time.Duration
+	WeightDeviation                float64
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region SweepResult //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SweepResult contains the aggregated outcome of all runs of a single combination of parameters.
+type SweepResult struct {
+	SweepParameters
+
+	Runs                    int
+	ResolutionProbability   float64
+	ConfirmationProbability float64
+	MedianResolutionTime    time.Duration
+	P95ResolutionTime       time.Duration
+	MeanOpinionFlips        float64
+}
+
+// newSweepResult aggregates the given outcomes of the runs with the given parameters.
+func newSweepResult(parameters SweepParameters, outcomes []*runOutcome) (result *SweepResult) {
+	result = &SweepResult{
+		SweepParameters: parameters,
+		Runs:            len(outcomes),
+	}
+
+	resolutionTimes := sortedResolutionTimes(outcomes)
+	result.ResolutionProbability = float64(len(resolutionTimes)) / float64(len(outcomes))
+	result.ConfirmationProbability = confirmationProbability(outcomes)
+	result.MedianResolutionTime = percentile(resolutionTimes, 0.5)
+	result.P95ResolutionTime = percentile(resolutionTimes, 0.95)
+	result.MeanOpinionFlips = meanOpinionFlips(outcomes)
+
+	return result
+}
+
+// SweepResults is a list of SweepResults.
+type SweepResults []*SweepResult
+
+func (s SweepResults) String() string {
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"TimeScaling", "Threshold", "ConfirmationThreshold", "BreakerCeiling", "AttackerWeight", "HonestVoters", "Latency", "WeightDeviation", "Runs", "ResolutionProbability", "ConfirmationProbability", "MedianResolutionTime", "P95ResolutionTime", "MeanOpinionFlips"})
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, result := range s {
+		table.Append([]string{
+			result.TimeScaling,
+			result.MetastabilityBreakingThreshold.String(),
+			fmt.Sprintf("%0.2f", result.ConfirmationThreshold),
+			fmt.Sprintf("%0.2f", result.BreakerCeiling),
+			fmt.Sprintf("%0.2f", result.AttackerWeight),
+			fmt.Sprintf("%d", result.HonestVoters),
+			result.Latency.String(),
+			fmt.Sprintf("%0.2f", result.WeightDeviation),
+			fmt.Sprintf("%d", result.Runs),
+			fmt.Sprintf("%0.2f", result.ResolutionProbability),
+			fmt.Sprintf("%0.2f", result.ConfirmationProbability),
+			result.MedianResolutionTime.String(),
+			result.P95ResolutionTime.String(),
+			fmt.Sprintf("%0.2f", result.MeanOpinionFlips),
+		})
+	}
+
+	table.Render()
+
+	return buf.String()
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,129 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweep(t *testing.T) {
+	sweep := &Sweep{
+		Scenario:                        NewSweepScenario(NewMinorityVoter),
+		MetastabilityBreakingThresholds: []time.Duration{1 * time.Second, 2 * time.Second},
+		AttackerWeights:                 []float64{0.2},
+		HonestVoters:                    []int{8},
+		Latencies:                       []time.Duration{0, 200 * time.Millisecond},
+		Runs:                            5,
+		BranchIDs:                       []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:                         30 * time.Second,
+	}
+
+	results, err := sweep.Run()
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	for _, result := range results {
+		assert.Equal(t, 5, result.Runs)
+		assert.Equal(t, DefaultConfirmationThreshold, result.ConfirmationThreshold)
+		assert.Equal(t, DefaultBreakerCeiling, result.BreakerCeiling)
+
+		assert.Equal(t, float64(1), result.ResolutionProbability, "the metastability breaker should resolve the conflict with a latency of %s", result.Latency)
+		assert.LessOrEqual(t, result.MedianResolutionTime, result.P95ResolutionTime)
+	}
+
+	t.Logf("\n%s", results)
+}
+
+func TestSweep_Grid(t *testing.T) {
+	sweep := &Sweep{
+		AttackerWeights:  []float64{0.1, 0.2},
+		Latencies:        []time.Duration{0, 100 * time.Millisecond},
+		WeightDeviations: []float64{0, 0.05, 0.1},
+		TimeScalings: map[string]TimeScaling{
+			"Step":   StepTimeScaling(0.5),
+			"Linear": LinearTimeScaling(),
+		},
+	}
+
+	grid := sweep.grid()
+	assert.Len(t, grid, 24)
+	assert.Equal(t, SweepParameters{TimeScaling: "Linear", ConfirmationThreshold: DefaultConfirmationThreshold, BreakerCeiling: DefaultBreakerCeiling, AttackerWeight: 0.1, Latency: 100 * time.Millisecond, WeightDeviation: 0.05}, grid[4])
+	assert.Equal(t, "Step", grid[12].TimeScaling, "the TimeScalings should be swept over in the order of their names")
+	assert.Equal(t, []SweepParameters{{ConfirmationThreshold: DefaultConfirmationThreshold, BreakerCeiling: DefaultBreakerCeiling}}, (&Sweep{}).grid())
+}
+
+func TestSweep_Thresholds(t *testing.T) {
+	sweep := &Sweep{
+		Scenario:                        NewSweepScenario(NewMinorityVoter),
+		MetastabilityBreakingThresholds: []time.Duration{1 * time.Second},
+		ConfirmationThresholds:          []float64{0.66, 0.8},
+		BreakerCeilings:                 []float64{0.5, 0.66},
+		AttackerWeights:                 []float64{0.2},
+		HonestVoters:                    []int{8},
+		Runs:                            20,
+		Seed:                            1,
+		BranchIDs:                       []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:                         10 * time.Second,
+	}
+
+	results, err := sweep.Run()
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, SweepParameters{MetastabilityBreakingThreshold: 1 * time.Second, ConfirmationThreshold: 0.8, BreakerCeiling: 0.5, AttackerWeight: 0.2, HonestVoters: 8}, results[2].SweepParameters)
+
+	for _, result := range results {
+		assert.Equal(t, float64(1), result.ResolutionProbability, "failed to resolve with a BreakerCeiling of %0.2f", result.BreakerCeiling)
+		assert.Equal(t, float64(1), result.ConfirmationProbability, "failed to confirm with a ConfirmationThreshold of %0.2f", result.ConfirmationThreshold)
+	}
+
+	t.Logf("\n%s", results)
+}
+
+func TestSweep_InvalidParameters(t *testing.T) {
+	for name, modify := range map[string]func(sweep *Sweep){
+		"no runs":                 func(sweep *Sweep) { sweep.Runs = 0 },
+		"no scenario":             func(sweep *Sweep) { sweep.Scenario = nil },
+		"no timeout":              func(sweep *Sweep) { sweep.Timeout = 0 },
+		"no honest voters":        func(sweep *Sweep) { sweep.HonestVoters = nil },
+		"zero honest voters":      func(sweep *Sweep) { sweep.HonestVoters = []int{8, 0} },
+		"attacker weight of 1":    func(sweep *Sweep) { sweep.AttackerWeights = []float64{1} },
+		"negative latency":        func(sweep *Sweep) { sweep.Latencies = []time.Duration{-time.Second} },
+		"negative deviation":      func(sweep *Sweep) { sweep.WeightDeviations = []float64{-0.1} },
+		"nil time scaling":        func(sweep *Sweep) { sweep.TimeScalings = map[string]TimeScaling{"Nil": nil} },
+		"negative threshold":      func(sweep *Sweep) { sweep.MetastabilityBreakingThresholds = []time.Duration{-time.Second} },
+		"confirmation threshold":  func(sweep *Sweep) { sweep.ConfirmationThresholds = []float64{0.4} },
+		"breaker ceiling above 1": func(sweep *Sweep) { sweep.BreakerCeilings = []float64{1.5} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			sweep := &Sweep{
+				Scenario:        NewSweepScenario(NewMinorityVoter),
+				AttackerWeights: []float64{0.2},
+				HonestVoters:    []int{8},
+				Runs:            1,
+				BranchIDs:       []BranchID{NewBranchID(1), NewBranchID(2)},
+				Timeout:         time.Second,
+			}
+			modify(sweep)
+
+			_, err := sweep.Run()
+			assert.ErrorIs(t, err, ErrInvalidParameter)
+		})
+	}
+}
+
+func TestSweep_InvalidScenario(t *testing.T) {
+	sweep := &Sweep{
+		Scenario: func(network *Network, parameters SweepParameters) {
+			network.AddVoters(parameters.HonestVoters, NewHonestVoter, FixedWeight(0.1))
+		},
+		HonestVoters: []int{8},
+		Runs:         1,
+		BranchIDs:    []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:      time.Second,
+	}
+
+	_, err := sweep.Run()
+	assert.ErrorIs(t, err, ErrInvalidWeightDistribution)
+}
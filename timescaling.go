@@ -0,0 +1,73 @@
+package metastabilitybreaker
+
+import (
+	"math"
+)
+
+// region TimeScaling //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TimeScaling maps the progress of a conflict (the time since the newest competing Branch was seen divided by the
+// MetastabilityBreakingThreshold) to the factor in [0, 1] that scales the window in which Voters prefer the lower hash.
+type TimeScaling func(progress float64) float64
+
+// LinearTimeScaling returns a TimeScaling that grows linearly until the threshold is reached.
+func LinearTimeScaling() TimeScaling {
+	return func(progress float64) float64 {
+		return clampProgress(progress)
+	}
+}
+
+// ExponentialTimeScaling returns a TimeScaling that grows slowly at first and accelerates towards the threshold. Larger
+// rates make the curve steeper.
+func ExponentialTimeScaling(rate float64) TimeScaling {
+	if rate == 0 {
+		return LinearTimeScaling()
+	}
+
+	return func(progress float64) float64 {
+		return math.Expm1(rate*clampProgress(progress)) / math.Expm1(rate)
+	}
+}
+
+// StepTimeScaling returns a TimeScaling that stays at 0 until the given fraction of the threshold passed and jumps to 1
+// afterwards.
+func StepTimeScaling(stepAt float64) TimeScaling {
+	return func(progress float64) float64 {
+		if progress < stepAt {
+			return 0
+		}
+
+		return 1
+	}
+}
+
+// LogisticTimeScaling returns an S-shaped TimeScaling with the given steepness that is centered around the given
+// midpoint (a fraction of the threshold). It is normalized to start at 0 and to reach 1 at the threshold.
+func LogisticTimeScaling(steepness, midpoint float64) TimeScaling {
+	if steepness == 0 {
+		return LinearTimeScaling()
+	}
+
+	logistic := func(progress float64) float64 {
+		return 1 / (1 + math.Exp(-steepness*(progress-midpoint)))
+	}
+	lowerBound, upperBound := logistic(0), logistic(1)
+
+	return func(progress float64) float64 {
+		return (logistic(clampProgress(progress)) - lowerBound) / (upperBound - lowerBound)
+	}
+}
+
+// SqrtTimeScaling returns a TimeScaling that grows quickly at first and slows down towards the threshold.
+func SqrtTimeScaling() TimeScaling {
+	return func(progress float64) float64 {
+		return math.Sqrt(clampProgress(progress))
+	}
+}
+
+// clampProgress limits the given progress to the interval [0, 1].
+func clampProgress(progress float64) float64 {
+	return math.Max(0, math.Min(progress, 1))
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
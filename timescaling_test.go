@@ -0,0 +1,61 @@
+package metastabilitybreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeScalings(t *testing.T) {
+	for name, timeScaling := range map[string]TimeScaling{
+		"Linear":      LinearTimeScaling(),
+		"Exponential": ExponentialTimeScaling(3),
+		"Step":        StepTimeScaling(0.5),
+		"Logistic":    LogisticTimeScaling(10, 0.5),
+		"Logistic0":   LogisticTimeScaling(0, 0.5),
+		"Sqrt":        SqrtTimeScaling(),
+	} {
+		assert.InDelta(t, 0, timeScaling(-1), 1e-9, "%s should start at 0", name)
+		assert.InDelta(t, 0, timeScaling(0), 1e-9, "%s should start at 0", name)
+		assert.InDelta(t, 1, timeScaling(1), 1e-9, "%s should reach 1 at the threshold", name)
+		assert.InDelta(t, 1, timeScaling(2), 1e-9, "%s should be capped at 1", name)
+
+		for progress := 0.1; progress <= 1; progress += 0.1 {
+			assert.GreaterOrEqual(t, timeScaling(progress), timeScaling(progress-0.1), "%s should be monotonic", name)
+		}
+	}
+
+	assert.Less(t, ExponentialTimeScaling(3)(0.5), LinearTimeScaling()(0.5))
+	assert.Greater(t, SqrtTimeScaling()(0.5), LinearTimeScaling()(0.5))
+	assert.Equal(t, LinearTimeScaling()(0.3), LogisticTimeScaling(0, 0.5)(0.3))
+}
+
+func TestSweep_TimeScalings(t *testing.T) {
+	sweep := &Sweep{
+		Scenario:                        NewSweepScenario(NewMinorityVoter),
+		MetastabilityBreakingThresholds: []time.Duration{1 * time.Second},
+		AttackerWeights:                 []float64{0.1, 0.2},
+		HonestVoters:                    []int{8},
+		TimeScalings: map[string]TimeScaling{
+			"Linear": LinearTimeScaling(),
+			"Step":   StepTimeScaling(0.5),
+		},
+		Runs:      20,
+		Seed:      1,
+		BranchIDs: []BranchID{NewBranchID(1), NewBranchID(2)},
+		Timeout:   10 * time.Second,
+	}
+
+	results, err := sweep.Run()
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	for _, result := range results {
+		assert.Equal(t, float64(1), result.ResolutionProbability, "%s failed to resolve the conflict against an attacker weight of %0.2f", result.TimeScaling, result.AttackerWeight)
+	}
+	assert.GreaterOrEqual(t, results[3].MedianResolutionTime, results[1].MedianResolutionTime, "the step should not open the window earlier than the linear time scaling")
+
+	t.Logf("\n%s", results)
+}
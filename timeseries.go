@@ -0,0 +1,284 @@
+package metastabilitybreaker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/types"
+)
+
+// region TimeSeriesRecorder ///////////////////////////////////////////////////////////////////////////////////////////
+
+// TimeSeriesRecorder samples the state of a Network in a fixed interval of the Clock of the Network, so simulations
+// that run in simulated time produce the same time series as simulations that run in real time. The competing
+// Branches are read from the perspective of the first online HonestVoter without its PerceptionNoise, so sampling does
+// not draw weight deviations that the Voter would otherwise draw later.
+type TimeSeriesRecorder struct {
+	network             *Network
+	interval            time.Duration
+	startTime           time.Time
+	opinionFlipCounter  *opinionFlipCounter
+	voteReceivedClosure *events.Closure
+	lastOpinionFlips    int
+	timeSeries          TimeSeries
+	stopped             bool
+	mutex               sync.Mutex
+}
+
+// NewTimeSeriesRecorder returns a TimeSeriesRecorder that samples the given Network in the given interval.
+func NewTimeSeriesRecorder(network *Network, interval time.Duration) *TimeSeriesRecorder {
+	opinionFlipCounter := newOpinionFlipCounter(network)
+
+	return &TimeSeriesRecorder{
+		network:             network,
+		interval:            interval,
+		opinionFlipCounter:  opinionFlipCounter,
+		voteReceivedClosure: events.NewClosure(opinionFlipCounter.VoteReceived),
+	}
+}
+
+// Start takes the first sample and schedules the following ones.
+func (t *TimeSeriesRecorder) Start() (err error) {
+	if t.interval <= 0 {
+		return fmt.Errorf("interval of the time series must be positive but is %s: %w", t.interval, ErrInvalidParameter)
+	}
+
+	t.startTime = t.network.Clock.Now()
+	t.network.VoteReceived.Attach(t.voteReceivedClosure)
+
+	var sample func()
+	sample = func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		if t.stopped {
+			return
+		}
+
+		t.timeSeries = append(t.timeSeries, t.sample())
+		t.network.Clock.AfterFunc(t.interval, sample)
+	}
+	sample()
+
+	return nil
+}
+
+// Stop prevents any further samples.
+func (t *TimeSeriesRecorder) Stop() {
+	t.network.VoteReceived.Detach(t.voteReceivedClosure)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.stopped = true
+}
+
+// TimeSeries returns the samples that were taken so far.
+func (t *TimeSeriesRecorder) TimeSeries() (timeSeries TimeSeries) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return append(timeSeries, t.timeSeries...)
+}
+
+// sample returns the current state of the Network.
+func (t *TimeSeriesRecorder) sample() (sample *TimeSeriesSample) {
+	now := t.network.Clock.Now()
+	sample = newTimeSeriesSample(t.network, now)
+	sample.Time = now.Sub(t.startTime)
+
+	opinionFlips := t.opinionFlipCounter.OpinionFlips()
+	if len(t.timeSeries) != 0 {
+		sample.OpinionFlipsPerSecond = float64(opinionFlips-t.lastOpinionFlips) / t.interval.Seconds()
+	}
+	t.lastOpinionFlips = opinionFlips
+
+	return sample
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TimeSeries ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TimeSeries is a list of TimeSeriesSamples in the order in which they were taken.
+type TimeSeries []*TimeSeriesSample
+
+// WriteCSV writes the TimeSeries as CSV with one row per sample. The time is written in seconds and every combination
+// of voter type and Branch that occurs in any of the samples gets its own weight column.
+func (t TimeSeries) WriteCSV(writer io.Writer) (err error) {
+	weightColumns := t.weightColumns()
+
+	header := []string{"time"}
+	for _, weightColumn := range weightColumns {
+		header = append(header, fmt.Sprintf("weight[%s,%d]", weightColumn.voterType, weightColumn.branchID))
+	}
+	header = append(header, "largestBranch", "secondLargestBranch", "weightGap", "breakerThreshold[timeScaling*breakerCeiling]", "honestOpinions", "opinionFlipsPerSecond")
+
+	csvWriter := csv.NewWriter(writer)
+	if err = csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+
+	for _, sample := range t {
+		row := []string{formatFloat(sample.Time.Seconds())}
+		for _, weightColumn := range weightColumns {
+			row = append(row, formatFloat(sample.BranchWeights[weightColumn.voterType][weightColumn.branchID]))
+		}
+		row = append(row,
+			strconv.Itoa(int(sample.LargestBranch)),
+			strconv.Itoa(int(sample.SecondLargestBranch)),
+			formatFloat(sample.WeightGap),
+			formatFloat(sample.BreakerThreshold),
+			strconv.Itoa(sample.HonestOpinions),
+			formatFloat(sample.OpinionFlipsPerSecond),
+		)
+
+		if err = csvWriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write time series: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err = csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+
+	return nil
+}
+
+// weightColumn identifies the weight of a voter type on a Branch.
+type weightColumn struct {
+	voterType string
+	branchID  BranchID
+}
+
+// weightColumns returns the combinations of voter types and Branches of all samples sorted by voter type and Branch.
+func (t TimeSeries) weightColumns() (weightColumns []weightColumn) {
+	seen := make(map[weightColumn]bool)
+	for _, sample := range t {
+		for voterType, weights := range sample.BranchWeights {
+			for branchID := range weights {
+				if column := (weightColumn{voterType: voterType, branchID: branchID}); !seen[column] {
+					seen[column] = true
+					weightColumns = append(weightColumns, column)
+				}
+			}
+		}
+	}
+
+	sort.Slice(weightColumns, func(i, j int) bool {
+		if weightColumns[i].voterType != weightColumns[j].voterType {
+			return weightColumns[i].voterType < weightColumns[j].voterType
+		}
+
+		return weightColumns[i].branchID < weightColumns[j].branchID
+	})
+
+	return weightColumns
+}
+
+// formatFloat formats the given float with the shortest representation that round-trips.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TimeSeriesSample /////////////////////////////////////////////////////////////////////////////////////////////
+
+// TimeSeriesSample is the state of a Network at a single point in time.
+type TimeSeriesSample struct {
+	// Time is the time since the start of the recording.
+	Time time.Duration
+
+	// BranchWeights are the weights of the Branches per voter type (see Network.ApprovalWeightByVoterType).
+	BranchWeights map[string]map[BranchID]float64
+
+	// LargestBranch is the heavier of the two competing Branches.
+	LargestBranch BranchID
+
+	// SecondLargestBranch is the lighter of the two competing Branches.
+	SecondLargestBranch BranchID
+
+	// WeightGap is the difference between the weights of the two competing Branches.
+	WeightGap float64
+
+	// BreakerThreshold is the weight gap up to which the metastability breaker favors the lower hash (the time scaling
+	// times the BreakerCeiling, 0 if the breaker is disabled). It is not scaled by the ConfirmationThreshold, because
+	// the breaker only used the confirmation threshold as its ceiling before the BreakerCeiling became configurable.
+	BreakerThreshold float64
+
+	// HonestOpinions is the number of distinct Branches that the online HonestVoters last voted for.
+	HonestOpinions int
+
+	// OpinionFlipsPerSecond is the rate in which the HonestVoters changed their opinion since the previous sample.
+	OpinionFlipsPerSecond float64
+
+	// Opinions are the Branches that the Voters last voted for (Voters that did not vote yet are missing).
+	Opinions map[VoterID]VoterOpinion
+}
+
+// VoterOpinion is the Branch that a Voter last voted for.
+type VoterOpinion struct {
+	// VoterType is the type of the Voter.
+	VoterType string
+
+	// BranchID is the Branch of the last statement of the Voter.
+	BranchID BranchID
+}
+
+// newTimeSeriesSample returns a TimeSeriesSample with the state of the given Network at the given time (without the
+// Time and the OpinionFlipsPerSecond, which depend on the previous samples).
+func newTimeSeriesSample(network *Network, now time.Time) (sample *TimeSeriesSample) {
+	sample = &TimeSeriesSample{
+		BranchWeights: network.ApprovalWeightByVoterType(),
+		Opinions:      make(map[VoterID]VoterOpinion),
+	}
+
+	honestOpinions := make(BranchIDs)
+	var perspective *HonestVoter
+	for _, voter := range network.Voters() {
+		lastStatement, voted := voter.ApprovalWeightManager().LastStatement(voter.ID())
+		if voted {
+			sample.Opinions[voter.ID()] = VoterOpinion{VoterType: voter.Type(), BranchID: lastStatement}
+		}
+
+		if voter.Type() != "HonestVoter" || !voter.Online() {
+			continue
+		}
+
+		if honestVoter, ok := voter.(*HonestVoter); ok && perspective == nil {
+			perspective = honestVoter
+		}
+
+		if voted {
+			honestOpinions[lastStatement] = types.Void
+		}
+	}
+	sample.HonestOpinions = len(honestOpinions)
+
+	if perspective == nil {
+		return sample
+	}
+
+	perception := &voterPerception{voter: perspective}
+	sample.LargestBranch, sample.SecondLargestBranch = perspective.consensus.competingBranches(perception)
+	if sample.SecondLargestBranch == UndefinedBranchID {
+		return sample
+	}
+
+	sample.WeightGap = perspective.consensus.deltaWeight(perception, sample.LargestBranch, sample.SecondLargestBranch)
+	if network.MetastabilityBreakingThreshold != 0 {
+		sample.BreakerThreshold = perspective.consensus.timeScaling(perception, now, sample.LargestBranch, sample.SecondLargestBranch) * network.BreakerCeiling
+	}
+
+	return sample
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,89 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSeriesRecorder(t *testing.T) {
+	network := NewNetwork(5 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(1)
+	defer network.Shutdown()
+	network.AddVoters(18, NewHonestVoter, FixedWeight(0.05))
+	network.AddVoters(1, NewSlowMinorityVoter, FixedWeight(0.1))
+
+	recorder := NewTimeSeriesRecorder(network, 100*time.Millisecond)
+	require.NoError(t, recorder.Start())
+	_, _, err := network.Simulate(5*time.Second, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+	recorder.Stop()
+
+	timeSeries := recorder.TimeSeries()
+	require.Len(t, timeSeries, 51)
+
+	var opinionFlipsPerSecond float64
+	var splitSamples int
+	for i, sample := range timeSeries {
+		assert.Equal(t, time.Duration(i)*100*time.Millisecond, sample.Time)
+		assert.GreaterOrEqual(t, sample.BreakerThreshold, 0.0)
+		assert.LessOrEqual(t, sample.BreakerThreshold, network.BreakerCeiling)
+		if i != 0 {
+			assert.GreaterOrEqual(t, sample.BreakerThreshold, timeSeries[i-1].BreakerThreshold, "the time scaling grows over time")
+		}
+
+		opinionFlipsPerSecond += sample.OpinionFlipsPerSecond
+		if sample.HonestOpinions > 1 {
+			splitSamples++
+		}
+	}
+	assert.Zero(t, timeSeries[0].HonestOpinions, "no HonestVoter voted before the start")
+	assert.NotZero(t, opinionFlipsPerSecond, "the SlowMinorityVoter should make the HonestVoters flip")
+	assert.NotZero(t, splitSamples, "the SlowMinorityVoter should split the HonestVoters")
+
+	lastSample := timeSeries[len(timeSeries)-1]
+	assert.InDelta(t, 0.9, lastSample.BranchWeights["HonestVoter"][NewBranchID(1)]+lastSample.BranchWeights["HonestVoter"][NewBranchID(2)], 1e-9)
+	assert.NotEqual(t, lastSample.LargestBranch, lastSample.SecondLargestBranch)
+	assert.Len(t, lastSample.Opinions, 19)
+	assert.Empty(t, timeSeries[0].Opinions)
+
+	// a recorder without an interval can not be started
+	assert.ErrorIs(t, NewTimeSeriesRecorder(network, 0).Start(), ErrInvalidParameter)
+}
+
+func TestTimeSeries_WriteCSV(t *testing.T) {
+	timeSeries := TimeSeries{
+		{
+			Time: 0,
+		},
+		{
+			Time: 500 * time.Millisecond,
+			BranchWeights: map[string]map[BranchID]float64{
+				"MinorityVoter": {NewBranchID(2): 0.2},
+				"HonestVoter":   {NewBranchID(1): 0.5, NewBranchID(2): 0.3},
+			},
+			LargestBranch:         NewBranchID(1),
+			SecondLargestBranch:   NewBranchID(2),
+			WeightGap:             0.2,
+			BreakerThreshold:      0.066,
+			HonestOpinions:        2,
+			OpinionFlipsPerSecond: 4,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, timeSeries.WriteCSV(&buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"time", "weight[HonestVoter,1]", "weight[HonestVoter,2]", "weight[MinorityVoter,2]", "largestBranch", "secondLargestBranch", "weightGap", "breakerThreshold[timeScaling*breakerCeiling]", "honestOpinions", "opinionFlipsPerSecond"},
+		{"0", "0", "0", "0", "0", "0", "0", "0", "0", "0"},
+		{"0.5", "0.5", "0.3", "0.2", "1", "2", "0.2", "0.066", "2", "4"},
+	}, records)
+}
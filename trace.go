@@ -0,0 +1,219 @@
+package metastabilitybreaker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/hive.go/events"
+)
+
+// region TraceEvent ///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TraceEvent is the kind of step in the life of a Vote that a TraceRecord describes.
+type TraceEvent string
+
+const (
+	// TraceEventSent is recorded when a Vote is sent through the Network.
+	TraceEventSent TraceEvent = "sent"
+
+	// TraceEventDelivered is recorded when a Vote reached a Voter.
+	TraceEventDelivered TraceEvent = "delivered"
+
+	// TraceEventProcessed is recorded when a Voter applied a Vote to its ApprovalWeightManager.
+	TraceEventProcessed TraceEvent = "processed"
+)
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TraceRecord //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TraceRecord is a single line of a vote trace.
+type TraceRecord struct {
+	// Time is the time of the Clock of the Network at which the event happened.
+	Time time.Time `json:"time"`
+
+	// Event is the kind of the event.
+	Event TraceEvent `json:"event"`
+
+	// Issuer is the Voter that issued the Vote.
+	Issuer VoterID `json:"issuer"`
+
+	// IssuerType is the type of the issuer (empty for the initial Votes that introduce the Branches).
+	IssuerType string `json:"issuerType,omitempty"`
+
+	// IssuerWeight is the weight of the issuer.
+	IssuerWeight float64 `json:"issuerWeight"`
+
+	// BranchID is the Branch that the Vote is for.
+	BranchID BranchID `json:"branchID"`
+
+	// Voter is the Voter that received or processed the Vote (empty for sent Votes).
+	Voter VoterID `json:"voter,omitempty"`
+
+	// VoterType is the type of the Voter that received or processed the Vote.
+	VoterType string `json:"voterType,omitempty"`
+
+	// VoterIsAttacker is true if the Voter that processed the Vote is an Attacker (Attackers perceive the exact weights
+	// without the PerceptionNoise).
+	VoterIsAttacker bool `json:"voterIsAttacker,omitempty"`
+
+	// Weights are the weights of the Branches that the Voter perceives after processing the Vote.
+	Weights map[BranchID]float64 `json:"weights,omitempty"`
+
+	// WeightDeviations are the deviations that the Voter perceives for the weights of the Branches (only recorded for
+	// honest Voters if the PerceptionNoise of the Network has a MaxWeightDeviation).
+	WeightDeviations map[BranchID]float64 `json:"weightDeviations,omitempty"`
+
+	// FavoredBranch is the Branch that the Voter favors after processing the Vote.
+	FavoredBranch BranchID `json:"favoredBranch,omitempty"`
+}
+
+// ReadTrace reads the TraceRecords of a JSON-lines vote trace.
+func ReadTrace(reader io.Reader) (records []*TraceRecord, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := &TraceRecord{}
+		if err = json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d of trace: %w", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+
+	return records, nil
+}
+
+// WriteTrace writes the given TraceRecords as a JSON-lines vote trace.
+func WriteTrace(writer io.Writer, records []*TraceRecord) (err error) {
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err = encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write trace: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region Tracer ///////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Tracer records every Vote that is sent, delivered and processed in a Network as JSON-lines. The Voters draw their
+// perceived weight deviations (see PerceptionNoise) as soon as they learn about a Branch, so recording their favored
+// Branches does not consume any randomness and seeded runs behave the same with and without a Tracer.
+type Tracer struct {
+	network  *Network
+	encoder  *json.Encoder
+	closures map[*events.Event]*events.Closure
+	err      error
+	mutex    sync.Mutex
+}
+
+// NewTracer returns a Tracer that writes the trace of the given Network to the given writer.
+func NewTracer(network *Network, writer io.Writer) (tracer *Tracer) {
+	tracer = &Tracer{
+		network:  network,
+		encoder:  json.NewEncoder(writer),
+		closures: make(map[*events.Event]*events.Closure),
+	}
+
+	tracer.closures[network.VoteReceived] = events.NewClosure(tracer.voteSent)
+	tracer.closures[network.VoteDelivered] = events.NewClosure(tracer.voteDelivered)
+	tracer.closures[network.VoteProcessed] = events.NewClosure(tracer.voteProcessed)
+
+	network.VoteReceived.AttachBefore(tracer.closures[network.VoteReceived])
+	network.VoteDelivered.Attach(tracer.closures[network.VoteDelivered])
+	network.VoteProcessed.Attach(tracer.closures[network.VoteProcessed])
+
+	return tracer
+}
+
+// Detach stops the recording.
+func (t *Tracer) Detach() {
+	for event, closure := range t.closures {
+		event.Detach(closure)
+	}
+}
+
+// Err returns the first error that occurred while writing the trace.
+func (t *Tracer) Err() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.err
+}
+
+func (t *Tracer) voteSent(vote *Vote) {
+	t.record(t.newRecord(TraceEventSent, vote))
+}
+
+func (t *Tracer) voteDelivered(voter Voter, vote *Vote) {
+	record := t.newRecord(TraceEventDelivered, vote)
+	record.Voter = voter.ID()
+	record.VoterType = voter.Type()
+
+	t.record(record)
+}
+
+func (t *Tracer) voteProcessed(voter *HonestVoter, vote *Vote) {
+	record := t.newRecord(TraceEventProcessed, vote)
+	record.Voter = voter.ID()
+	record.VoterType = t.voterType(voter.ID())
+	record.VoterIsAttacker = !t.network.perceivesNoise(voter.ID())
+	record.Weights = voter.ApprovalWeightManager().Snapshot().Weights()
+	record.FavoredBranch = voter.consensus.FavoredBranch()
+	if t.network.PerceptionNoise.MaxWeightDeviation != 0 && !record.VoterIsAttacker {
+		record.WeightDeviations = voter.consensus.weightDeviations.Deviations()
+	}
+
+	t.record(record)
+}
+
+// newRecord returns a TraceRecord of the given event with the details of the given Vote.
+func (t *Tracer) newRecord(event TraceEvent, vote *Vote) *TraceRecord {
+	return &TraceRecord{
+		Time:         t.network.Clock.Now(),
+		Event:        event,
+		Issuer:       vote.Issuer,
+		IssuerType:   t.voterType(vote.Issuer),
+		IssuerWeight: t.network.WeightDistribution.Weight(vote.Issuer),
+		BranchID:     vote.BranchID,
+	}
+}
+
+// voterType returns the type of the Voter with the given identifier (empty if it is not part of the Network).
+func (t *Tracer) voterType(voterID VoterID) string {
+	voter, exists := t.network.Voter(voterID)
+	if !exists {
+		return ""
+	}
+
+	return voter.Type()
+}
+
+// record writes the given TraceRecord.
+func (t *Tracer) record(record *TraceRecord) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.err != nil {
+		return
+	}
+
+	t.err = t.encoder.Encode(record)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
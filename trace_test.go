@@ -0,0 +1,133 @@
+package metastabilitybreaker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer(t *testing.T) {
+	trace := traceMinorityVoter(t, 2*time.Second, 0, 1)
+
+	records, err := ReadTrace(bytes.NewReader(trace))
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+
+	recordsByEvent := make(map[TraceEvent][]*TraceRecord)
+	for _, record := range records {
+		recordsByEvent[record.Event] = append(recordsByEvent[record.Event], record)
+	}
+
+	// every Vote that is sent through the Network is delivered to all 5 Voters
+	assert.Len(t, recordsByEvent[TraceEventDelivered], 5*len(recordsByEvent[TraceEventSent]))
+
+	// the initial Votes introduce the Branches and are not issued by any Voter
+	assert.Equal(t, "", recordsByEvent[TraceEventSent][0].IssuerType)
+	assert.Equal(t, NewBranchID(1), recordsByEvent[TraceEventSent][0].BranchID)
+
+	for _, record := range recordsByEvent[TraceEventProcessed] {
+		assert.NotEmpty(t, record.Weights)
+		assert.Contains(t, record.Weights, record.FavoredBranch)
+		assert.False(t, record.Time.Before(simulationStartTime))
+	}
+
+	// VoterIDs are unique across Networks, so the traces are compared without them
+	repeatedRecords, err := ReadTrace(bytes.NewReader(traceMinorityVoter(t, 2*time.Second, 0, 1)))
+	require.NoError(t, err)
+	assert.Equal(t, withoutVoterIDs(records), withoutVoterIDs(repeatedRecords), "the same seed should produce the same trace")
+}
+
+func TestTracer_Attacker(t *testing.T) {
+	trace, resolutionTime := traceTimeScalingAttacker(t, 1, true)
+
+	records, err := ReadTrace(bytes.NewReader(trace))
+	require.NoError(t, err)
+
+	var attackerVotes, attackerDeliveries int
+	for _, record := range records {
+		if record.IssuerType != "TimeScalingAttacker" {
+			continue
+		}
+
+		switch record.Event {
+		case TraceEventSent:
+			attackerVotes++
+		case TraceEventDelivered:
+			attackerDeliveries++
+		}
+	}
+	assert.Equal(t, 1, attackerVotes, "the vote of the attacker should be recorded when it is sent")
+	assert.Equal(t, 11, attackerDeliveries, "the vote of the attacker should be delivered to all 11 Voters")
+
+	repeatedTrace, repeatedResolutionTime := traceTimeScalingAttacker(t, 1, true)
+	repeatedRecords, err := ReadTrace(bytes.NewReader(repeatedTrace))
+	require.NoError(t, err)
+	assert.Equal(t, withoutVoterIDs(records), withoutVoterIDs(repeatedRecords), "the same seed should produce the same trace")
+	assert.Equal(t, resolutionTime, repeatedResolutionTime)
+
+	_, untracedResolutionTime := traceTimeScalingAttacker(t, 1, false)
+	assert.Equal(t, resolutionTime, untracedResolutionTime, "the Tracer should not change the outcome of a seeded run")
+}
+
+func withoutVoterIDs(records []*TraceRecord) (strippedRecords []TraceRecord) {
+	for _, record := range records {
+		strippedRecord := *record
+		strippedRecord.Issuer = 0
+		strippedRecord.Voter = 0
+		strippedRecords = append(strippedRecords, strippedRecord)
+	}
+
+	return strippedRecords
+}
+
+func traceMinorityVoter(t *testing.T, metastabilityBreakingThreshold time.Duration, weightDeviation float64, seed int64) (trace []byte) {
+	network := NewNetwork(metastabilityBreakingThreshold)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(seed)
+	network.PerceptionNoise.MaxUpdateDelay = 200 * time.Millisecond
+	network.PerceptionNoise.MaxWeightDeviation = weightDeviation
+	network.AddVoters(4, NewHonestVoter, FixedWeight(0.2))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.2))
+	defer network.Shutdown()
+
+	var buf bytes.Buffer
+	tracer := NewTracer(network, &buf)
+	resolved, _, err := network.Simulate(time.Minute, NewBranchID(1), NewBranchID(2))
+	require.NoError(t, err)
+	assert.True(t, resolved)
+	tracer.Detach()
+	require.NoError(t, tracer.Err())
+
+	return buf.Bytes()
+}
+
+// traceTimeScalingAttacker runs a TimeScalingAttacker against noisy HonestVoters and returns the trace (empty if the
+// run is not traced) and the resolution time.
+func traceTimeScalingAttacker(t *testing.T, seed int64, traced bool) (trace []byte, resolutionTime time.Duration) {
+	network := NewNetwork(1 * time.Second)
+	network.Clock = NewSimulatedClock(simulationStartTime)
+	network.Random = NewRandom(seed)
+	network.PerceptionNoise = PerceptionNoise{MaxWeightDeviation: 0.05, MaxUpdateDelay: 200 * time.Millisecond}
+	network.AddVoters(9, NewHonestVoter, FixedWeight(0.08))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(0.18))
+	network.AddVoters(1, NewTimeScalingAttacker(3, 250*time.Millisecond), FixedWeight(0.1))
+	defer network.Shutdown()
+
+	var buf bytes.Buffer
+	if traced {
+		tracer := NewTracer(network, &buf)
+		defer func() {
+			tracer.Detach()
+			require.NoError(t, tracer.Err())
+		}()
+	}
+
+	resolved, resolutionTime, err := network.Simulate(time.Minute, NewBranchID(10), NewBranchID(11))
+	require.NoError(t, err)
+	require.True(t, resolved)
+
+	return buf.Bytes(), resolutionTime
+}
@@ -2,6 +2,10 @@ package metastabilitybreaker
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/iotaledger/hive.go/events"
@@ -13,6 +17,10 @@ import (
 type Vote struct {
 	Issuer   VoterID
 	BranchID BranchID
+
+	// deliveryDelay allows the issuer to delay the delivery of the Vote to individual recipients (nil delivers the Vote
+	// to all Voters immediately).
+	deliveryDelay func(recipient Voter) time.Duration
 }
 
 func (v *Vote) String() string {
@@ -30,13 +38,21 @@ type HonestVoter struct {
 	approvalWeightManager *ApprovalWeightManager
 	consensus             *Consensus
 	network               *Network
+	faultModel            FaultModel
+	lastVoteTime          time.Time
+	missedVotes           map[VoterID]*Vote
+	missedVotesMutex      sync.Mutex
+	latestVotes           map[VoterID]*Vote
+	latestVotesMutex      sync.Mutex
 }
 
 // NewHonestVoter returns a new HonestVoter instance.
 func NewHonestVoter(network *Network) (voter Voter) {
 	honestVoter := &HonestVoter{
-		id:      NewVoterID(),
-		network: network,
+		id:          NewVoterID(),
+		network:     network,
+		missedVotes: make(map[VoterID]*Vote),
+		latestVotes: make(map[VoterID]*Vote),
 	}
 	honestVoter.branchManager = NewBranchManager(honestVoter)
 	honestVoter.approvalWeightManager = NewApprovalWeightManager(honestVoter)
@@ -70,22 +86,129 @@ func (v *HonestVoter) Network() *Network {
 	return v.network
 }
 
+// Online returns true if the Voter is currently online (it is always online if it has no FaultModel).
+func (v *HonestVoter) Online() bool {
+	return v.faultModel == nil || v.faultModel.Online(v.network.Clock.Now())
+}
+
+// OnVoteReceived processes the given Vote. While the Voter is offline, it only keeps the latest missed Vote of every
+// issuer (the earlier ones would be overwritten when it catches up anyway). Votes whose processing is delayed by the
+// PerceptionNoise are dropped if a newer Vote of the same issuer was received in the meantime, so the delay can not
+// reorder the Votes of an issuer.
 func (v *HonestVoter) OnVoteReceived(vote *Vote) {
-	v.approvalWeightManager.ProcessVote(vote)
+	v.latestVotesMutex.Lock()
+	v.latestVotes[vote.Issuer] = vote
+	v.latestVotesMutex.Unlock()
+
+	if !v.Online() {
+		v.missedVotesMutex.Lock()
+		v.missedVotes[vote.Issuer] = vote
+		v.missedVotesMutex.Unlock()
+
+		return
+	}
+
+	v.catchUp()
+
+	if vote.Issuer == v.id || !v.network.perceivesNoise(v.id) {
+		v.processVote(vote)
+
+		return
+	}
+
+	if updateDelay := v.network.PerceptionNoise.updateDelay(v.network.Random); updateDelay > 0 {
+		v.network.schedule(updateDelay, func() {
+			if v.isLatestVote(vote) {
+				v.processVote(vote)
+			}
+		})
+
+		return
+	}
+
+	v.processVote(vote)
 }
 
 func (v *HonestVoter) SendVote() (opinionChanged bool) {
-	favoredBranch := v.consensus.FavoredBranch()
-	if favoredBranch == v.approvalWeightManager.lastStatements[v.id] {
+	return v.sendVote(v.consensus.FavoredBranch)
+}
+
+// sendVote votes for the Branch that the given opinion returns if it differs from the last statement of the Voter (or
+// if a heartbeat is due). The opinion is only formed while the Voter is online and after it caught up.
+func (v *HonestVoter) sendVote(opinion func() BranchID) (opinionChanged bool) {
+	if !v.Online() {
+		return false
+	}
+
+	v.catchUp()
+
+	favoredBranch := opinion()
+	if lastStatement, _ := v.approvalWeightManager.LastStatement(v.id); favoredBranch == lastStatement {
+		if v.heartbeatDue() {
+			v.vote(favoredBranch)
+		}
+
 		return false
 	}
 
+	v.vote(favoredBranch)
+
+	return true
+}
+
+// vote issues a Vote for the given Branch.
+func (v *HonestVoter) vote(branchID BranchID) {
+	v.lastVoteTime = v.network.Clock.Now()
+
 	v.network.VoteReceived.Trigger(&Vote{
 		Issuer:   v.id,
-		BranchID: favoredBranch,
+		BranchID: branchID,
 	})
+}
 
-	return true
+// heartbeatDue returns true if the Voter needs to repeat its statement to prevent it from expiring.
+func (v *HonestVoter) heartbeatDue() bool {
+	return v.network.HeartbeatInterval != 0 && v.network.Clock.Now().Sub(v.lastVoteTime) >= v.network.HeartbeatInterval
+}
+
+// catchUp processes the votes that were missed while the Voter was offline (ordered by their issuer, so the catch-up
+// is deterministic).
+func (v *HonestVoter) catchUp() {
+	v.missedVotesMutex.Lock()
+	defer v.missedVotesMutex.Unlock()
+
+	if len(v.missedVotes) == 0 {
+		return
+	}
+
+	issuers := make([]VoterID, 0, len(v.missedVotes))
+	for issuer := range v.missedVotes {
+		issuers = append(issuers, issuer)
+	}
+	sort.Slice(issuers, func(i, j int) bool {
+		return issuers[i] < issuers[j]
+	})
+
+	for _, issuer := range issuers {
+		v.processVote(v.missedVotes[issuer])
+	}
+	v.missedVotes = make(map[VoterID]*Vote)
+}
+
+// isLatestVote returns true if the given Vote is the latest Vote that the Voter received from its issuer.
+func (v *HonestVoter) isLatestVote(vote *Vote) bool {
+	v.latestVotesMutex.Lock()
+	defer v.latestVotesMutex.Unlock()
+
+	return v.latestVotes[vote.Issuer] == vote
+}
+
+// processVote applies the given Vote to the ApprovalWeightManager of the Voter.
+func (v *HonestVoter) processVote(vote *Vote) {
+	v.approvalWeightManager.ProcessVote(vote)
+	v.consensus.drawWeightDeviations()
+
+	v.network.VoteProcessed.Trigger(v, vote)
 }
 
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -110,16 +233,19 @@ func (m *MinorityVoter) Type() string {
 	return "MinorityVoter"
 }
 
+// IsAttacker marks the MinorityVoter as an Attacker.
+func (m *MinorityVoter) IsAttacker() {}
+
 func (m *MinorityVoter) VoteProcessed(vote *Vote) {
-	if issuer, issuerExists := m.Network().Voters[vote.Issuer]; issuerExists && issuer.Type() == "HonestVoter" {
+	if issuer, issuerExists := m.Network().Voter(vote.Issuer); issuerExists && issuer.Type() == "HonestVoter" {
 		_, secondLargestBranch := m.HonestVoter.consensus.CompetingBranches()
 
-		go func() {
+		m.network.schedule(0, func() {
 			m.network.VoteReceived.Trigger(&Vote{
 				Issuer:   m.id,
 				BranchID: secondLargestBranch,
 			})
-		}()
+		})
 	}
 }
 
@@ -149,15 +275,15 @@ func NewLowerHashVoter(network *Network) (voter Voter) {
 }
 
 func (m *LowerHashVoter) VoteProcessed(vote *Vote) {
-	if issuer, issuerExists := m.Network().Voters[vote.Issuer]; issuerExists && issuer.Type() == "HonestVoter" {
+	if issuer, issuerExists := m.Network().Voter(vote.Issuer); issuerExists && issuer.Type() == "HonestVoter" {
 		lowerBranch := vote.BranchID - 1
 
-		go func() {
+		m.network.schedule(0, func() {
 			m.network.VoteReceived.Trigger(&Vote{
 				Issuer:   m.id,
 				BranchID: lowerBranch,
 			})
-		}()
+		})
 	}
 }
 
@@ -170,6 +296,9 @@ func (m *LowerHashVoter) Type() string {
 	return "LowerHashVoter"
 }
 
+// IsAttacker marks the LowerHashVoter as an Attacker.
+func (m *LowerHashVoter) IsAttacker() {}
+
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // region SlowMinorityVoter ///////////////////////////////////////////////////////////////////////////////////////////////
@@ -185,19 +314,7 @@ func NewSlowMinorityVoter(network *Network) (voter Voter) {
 		HonestVoter: NewHonestVoter(network).(*HonestVoter),
 	}
 
-	network.BeforeNextVote.Attach(events.NewClosure(slowMinorityVoter.BeforeNextVote))
-	network.VoteReceived.AttachAfter(events.NewClosure(func(vote *Vote) {
-		issuer, exists := network.Voters[vote.Issuer]
-		if !exists {
-			return
-		}
-
-		fmt.Println("==", issuer.Type(), issuer.ID(), "votes for", vote.BranchID)
-		fmt.Println()
-		fmt.Println(slowMinorityVoter.approvalWeightManager.StringBranchWeights())
-		fmt.Printf("lowerHashThreshold = %0.2f\n", slowMinorityVoter.consensus.timeScaling(BranchID(1), BranchID(2)) * confirmationThreshold)
-		fmt.Println()
-	}))
+	network.attachVoterHandler(slowMinorityVoter.ID(), network.BeforeNextVote, slowMinorityVoter.BeforeNextVote)
 
 	return slowMinorityVoter
 }
@@ -215,15 +332,13 @@ func (m *SlowMinorityVoter) BeforeNextVote(voter Voter) {
 		minorityBranch = largestBranch
 	}
 
-	reverseSimulatedVote := m.simulateVote(voter.ID(), predictedBranch)
-	reverseSimulatedAttackerVote := m.simulateVote(m.ID(), minorityBranch)
-	m.consensus.timeOffset = 100 * time.Millisecond
-	predictedBranchAfterAttack := m.consensus.FavoredBranch()
-	m.consensus.timeOffset = 0 * time.Millisecond
-	reverseSimulatedVote()
-	reverseSimulatedAttackerVote()
+	now := m.network.Clock.Now()
+	snapshotAfterAttack := NewSnapshot(m).
+		WithVote(&Vote{Issuer: voter.ID(), BranchID: predictedBranch}, now).
+		WithVote(&Vote{Issuer: m.ID(), BranchID: minorityBranch}, now)
+	predictedBranchAfterAttack := m.consensus.FavoredBranchAt(snapshotAfterAttack, now.Add(voteInterval))
 
-	if predictedBranchAfterAttack != minorityBranch && m.approvalWeightManager.lastStatements[m.id] != minorityBranch {
+	if lastStatement, _ := m.approvalWeightManager.LastStatement(m.id); predictedBranchAfterAttack != minorityBranch && lastStatement != minorityBranch {
 		m.network.VoteReceived.Trigger(&Vote{
 			Issuer:   m.id,
 			BranchID: minorityBranch,
@@ -231,42 +346,531 @@ func (m *SlowMinorityVoter) BeforeNextVote(voter Voter) {
 	}
 }
 
-func (m *SlowMinorityVoter) simulateVote(voterID VoterID, branchID BranchID) (reverser func()) {
-	m.BranchManager().RegisterBranch(branchID)
+func (m *SlowMinorityVoter) SendVote() (opinionChanged bool) {
+	// do nothing, we have our own voting strategy based on the behavior of others
+	return false
+}
+
+func (m *SlowMinorityVoter) Type() string {
+	return "SlowMinorityVoter"
+}
+
+// IsAttacker marks the SlowMinorityVoter as an Attacker.
+func (m *SlowMinorityVoter) IsAttacker() {}
 
-	lastBranchID, exists := m.approvalWeightManager.LastStatements()[voterID]
-	if !exists {
-		m.approvalWeightManager.updateWeight(branchID, m.network.WeightDistribution.Weight(voterID))
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-		m.approvalWeightManager.lastStatements[voterID] = branchID
+// region WithholdingVoter /////////////////////////////////////////////////////////////////////////////////////////////
 
-		return func() {
-			delete(m.approvalWeightManager.lastStatements, voterID)
+// WithholdingVoter stays silent until the weight gap between the two competing Branches falls within its own weight and
+// the time scaling of the metastability breaker reaches the given revealTimeScaling. It then reveals its weight on the
+// competing Branch that is currently not favored, to test if the breaker can be gamed by last-second reveals.
+type WithholdingVoter struct {
+	*HonestVoter
 
-			m.approvalWeightManager.updateWeight(branchID, -m.network.WeightDistribution.Weight(voterID))
+	revealTimeScaling float64
+	revealed          bool
+	revealedMutex     sync.Mutex
+}
+
+// NewWithholdingVoter returns a VoterFactory for WithholdingVoters that reveal their weight once the time scaling of
+// the metastability breaker reaches the given value (between 0 and 1).
+func NewWithholdingVoter(revealTimeScaling float64) VoterFactory {
+	return func(network *Network) Voter {
+		withholdingVoter := &WithholdingVoter{
+			HonestVoter:       NewHonestVoter(network).(*HonestVoter),
+			revealTimeScaling: revealTimeScaling,
 		}
+
+		network.attachVoterHandler(withholdingVoter.ID(), network.BeforeNextVote, withholdingVoter.BeforeNextVote)
+
+		return withholdingVoter
 	}
+}
 
-	m.approvalWeightManager.updateWeight(lastBranchID, -m.network.WeightDistribution.Weight(voterID))
-	m.approvalWeightManager.updateWeight(branchID, m.network.WeightDistribution.Weight(voterID))
+func (w *WithholdingVoter) BeforeNextVote(voter Voter) {
+	if voter.Type() != "HonestVoter" {
+		return
+	}
 
-	m.approvalWeightManager.lastStatements[voterID] = branchID
+	w.revealedMutex.Lock()
+	defer w.revealedMutex.Unlock()
 
-	return func() {
-		m.approvalWeightManager.lastStatements[voterID] = lastBranchID
+	if w.revealed {
+		return
+	}
+
+	largestBranch, secondLargestBranch := w.consensus.CompetingBranches()
+	if secondLargestBranch == UndefinedBranchID {
+		return
+	}
+
+	perception := w.consensus.livePerception()
+	if w.consensus.deltaWeight(perception, largestBranch, secondLargestBranch) > w.network.WeightDistribution.Weight(w.id) {
+		return
+	}
+
+	if w.network.MetastabilityBreakingThreshold != 0 && w.consensus.timeScaling(perception, w.network.Clock.Now(), largestBranch, secondLargestBranch) < w.revealTimeScaling {
+		return
+	}
 
-		m.approvalWeightManager.updateWeight(lastBranchID, m.network.WeightDistribution.Weight(voterID))
-		m.approvalWeightManager.updateWeight(branchID, -m.network.WeightDistribution.Weight(voterID))
+	revealedBranch := largestBranch
+	if w.consensus.FavoredBranch() == largestBranch {
+		revealedBranch = secondLargestBranch
 	}
+
+	w.revealed = true
+	w.network.VoteReceived.Trigger(&Vote{
+		Issuer:   w.id,
+		BranchID: revealedBranch,
+	})
 }
 
-func (m *SlowMinorityVoter) SendVote() (opinionChanged bool) {
+// Revealed returns true if the WithholdingVoter revealed its weight already.
+func (w *WithholdingVoter) Revealed() bool {
+	w.revealedMutex.Lock()
+	defer w.revealedMutex.Unlock()
+
+	return w.revealed
+}
+
+func (w *WithholdingVoter) SendVote() (opinionChanged bool) {
 	// do nothing, we have our own voting strategy based on the behavior of others
 	return false
 }
 
-func (m *SlowMinorityVoter) Type() string {
-	return "SlowMinorityVoter"
+func (w *WithholdingVoter) Type() string {
+	return "WithholdingVoter"
+}
+
+// IsAttacker marks the WithholdingVoter as an Attacker.
+func (w *WithholdingVoter) IsAttacker() {}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region TimeScalingAttacker //////////////////////////////////////////////////////////////////////////////////////////
+
+// TimeScalingAttacker targets the SolidificationTime that the metastability breaker uses to compute the time scaling.
+// Once the voting starts, it introduces a new conflicting Branch (with a lower hash than all known Branches) and sends
+// its vote through the Network, but delays the delivery to the honest Voters in groups that are delayed against each
+// other, so the time scaling of the honest Voters diverges.
+type TimeScalingAttacker struct {
+	*HonestVoter
+
+	groups     int
+	groupDelay time.Duration
+	started    sync.Once
+}
+
+// NewTimeScalingAttacker returns a VoterFactory for TimeScalingAttackers that split the honest Voters into the given
+// amount of groups and deliver the new Branch to each group groupDelay after the previous one. It panics if the amount
+// of groups is not positive.
+func NewTimeScalingAttacker(groups int, groupDelay time.Duration) VoterFactory {
+	if groups <= 0 {
+		panic(fmt.Sprintf("TimeScalingAttacker needs a positive number of groups but got %d", groups))
+	}
+
+	return func(network *Network) Voter {
+		timeScalingAttacker := &TimeScalingAttacker{
+			HonestVoter: NewHonestVoter(network).(*HonestVoter),
+			groups:      groups,
+			groupDelay:  groupDelay,
+		}
+
+		network.attachVoterHandler(timeScalingAttacker.ID(), network.BeforeNextVote, timeScalingAttacker.BeforeNextVote)
+
+		return timeScalingAttacker
+	}
+}
+
+func (t *TimeScalingAttacker) BeforeNextVote(voter Voter) {
+	if voter.Type() != "HonestVoter" {
+		return
+	}
+
+	t.started.Do(func() {
+		groupByVoter := t.honestVoterGroups()
+
+		t.network.VoteReceived.Trigger(&Vote{
+			Issuer:   t.id,
+			BranchID: t.lowestBranch().lowerHash(),
+			deliveryDelay: func(recipient Voter) time.Duration {
+				return time.Duration(groupByVoter[recipient.ID()]) * t.groupDelay
+			},
+		})
+	})
+}
+
+func (t *TimeScalingAttacker) SendVote() (opinionChanged bool) {
+	// do nothing, we have our own voting strategy based on the behavior of others
+	return false
+}
+
+func (t *TimeScalingAttacker) Type() string {
+	return "TimeScalingAttacker"
+}
+
+// IsAttacker marks the TimeScalingAttacker as an Attacker.
+func (t *TimeScalingAttacker) IsAttacker() {}
+
+// lowestBranch returns the Branch with the lowest hash that the attacker knows.
+func (t *TimeScalingAttacker) lowestBranch() (lowestBranch BranchID) {
+	for branchID := range t.branchManager.BranchIDs() {
+		if lowestBranch == UndefinedBranchID || branchID < lowestBranch {
+			lowestBranch = branchID
+		}
+	}
+
+	return lowestBranch
+}
+
+// honestVoterGroups splits the honest Voters of the Network round-robin into the configured amount of groups and
+// returns the index of the group of every honest Voter (all other Voters are not part of the map).
+func (t *TimeScalingAttacker) honestVoterGroups() (groupByVoter map[VoterID]int) {
+	groupByVoter = make(map[VoterID]int)
+
+	var i int
+	for _, voter := range t.network.Voters() {
+		if voter.Type() != "HonestVoter" {
+			continue
+		}
+
+		groupByVoter[voter.ID()] = i % t.groups
+		i++
+	}
+
+	return groupByVoter
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region RandomVoter //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RandomVoter votes for a uniformly random Branch out of the Branches it knows every time it gets to vote.
+type RandomVoter struct {
+	*HonestVoter
+}
+
+// NewRandomVoter returns a new RandomVoter instance.
+func NewRandomVoter(network *Network) (voter Voter) {
+	return &RandomVoter{
+		HonestVoter: NewHonestVoter(network).(*HonestVoter),
+	}
+}
+
+func (r *RandomVoter) SendVote() (opinionChanged bool) {
+	randomBranch := randomBranch(r.network.Random, r.branchManager.BranchIDs())
+	if lastStatement, _ := r.approvalWeightManager.LastStatement(r.id); randomBranch == UndefinedBranchID || randomBranch == lastStatement {
+		return false
+	}
+
+	r.vote(randomBranch)
+
+	return true
+}
+
+func (r *RandomVoter) Type() string {
+	return "RandomVoter"
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region StubbornVoter ////////////////////////////////////////////////////////////////////////////////////////////////
+
+// StubbornVoter votes for the Branch that it favors when it gets to vote for the first time and never changes its
+// opinion afterwards.
+type StubbornVoter struct {
+	*HonestVoter
+}
+
+// NewStubbornVoter returns a new StubbornVoter instance.
+func NewStubbornVoter(network *Network) (voter Voter) {
+	return &StubbornVoter{
+		HonestVoter: NewHonestVoter(network).(*HonestVoter),
+	}
+}
+
+func (s *StubbornVoter) SendVote() (opinionChanged bool) {
+	if _, hasVoted := s.approvalWeightManager.LastStatement(s.id); hasVoted {
+		return false
+	}
+
+	favoredBranch := s.consensus.FavoredBranch()
+	if favoredBranch == UndefinedBranchID {
+		return false
+	}
+
+	s.vote(favoredBranch)
+
+	return true
+}
+
+func (s *StubbornVoter) Type() string {
+	return "StubbornVoter"
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region NoisyHonestVoter /////////////////////////////////////////////////////////////////////////////////////////////
+
+// NoisyHonestVoter follows the Consensus like an HonestVoter, but every time it casts a vote, it votes for a random
+// other Branch with the given flip probability.
+type NoisyHonestVoter struct {
+	*HonestVoter
+
+	flipProbability float64
+}
+
+// NewNoisyHonestVoter returns a VoterFactory for NoisyHonestVoters with the given flip probability.
+func NewNoisyHonestVoter(flipProbability float64) VoterFactory {
+	return func(network *Network) Voter {
+		return &NoisyHonestVoter{
+			HonestVoter:     NewHonestVoter(network).(*HonestVoter),
+			flipProbability: flipProbability,
+		}
+	}
+}
+
+func (n *NoisyHonestVoter) SendVote() (opinionChanged bool) {
+	return n.sendVote(n.noisyOpinion)
+}
+
+func (n *NoisyHonestVoter) Type() string {
+	return "NoisyHonestVoter"
+}
+
+// noisyOpinion returns the favored Branch of the Consensus, but flips to a random other Branch with the configured
+// flip probability (every time it is called, so a converged NoisyHonestVoter keeps flipping).
+func (n *NoisyHonestVoter) noisyOpinion() (branchID BranchID) {
+	branchID = n.consensus.FavoredBranch()
+	if n.network.Random.Float64() >= n.flipProbability {
+		return branchID
+	}
+
+	otherBranches := n.branchManager.BranchIDs()
+	delete(otherBranches, branchID)
+	if flippedBranch := randomBranch(n.network.Random, otherBranches); flippedBranch != UndefinedBranchID {
+		return flippedBranch
+	}
+
+	return branchID
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region AdaptiveAttacker /////////////////////////////////////////////////////////////////////////////////////////////
+
+// AdaptiveAttacker is a generalization of the SlowMinorityVoter. Before an honest Voter casts a vote, it takes a
+// Snapshot of the perception of that Voter and searches lookahead steps ahead over all possible orderings of the
+// following honest votes. It then picks the vote that maximizes the (worst case) amount of steps until the honest
+// Voters agree. Since the honest decisions are evaluated with the Consensus of the Voters, it adapts to whatever rule
+// the Network uses.
+type AdaptiveAttacker struct {
+	*HonestVoter
+
+	lookahead int
+}
+
+// NewAdaptiveAttacker returns a VoterFactory for AdaptiveAttackers that search the given amount of votes ahead.
+func NewAdaptiveAttacker(lookahead int) VoterFactory {
+	return func(network *Network) Voter {
+		adaptiveAttacker := &AdaptiveAttacker{
+			HonestVoter: NewHonestVoter(network).(*HonestVoter),
+			lookahead:   lookahead,
+		}
+
+		network.attachVoterHandler(adaptiveAttacker.ID(), network.BeforeNextVote, adaptiveAttacker.BeforeNextVote)
+
+		return adaptiveAttacker
+	}
+}
+
+func (a *AdaptiveAttacker) BeforeNextVote(voter Voter) {
+	if voter.Type() != "HonestVoter" {
+		return
+	}
+
+	now := a.network.Clock.Now()
+	root := NewSnapshot(voter)
+	if _, wantsToChangeOpinion := a.favoredBranch(root, now, voter.ID()); !wantsToChangeOpinion {
+		return
+	}
+
+	honestVoters := a.honestVoters()
+	lastStatement, _ := a.approvalWeightManager.LastStatement(a.id)
+	bestBranch, bestScore := lastStatement, searchScore{steps: -1}
+	for _, candidate := range a.candidates(root, lastStatement) {
+		state := root
+		if candidate != UndefinedBranchID {
+			state = state.WithVote(&Vote{Issuer: a.id, BranchID: candidate}, now)
+		}
+
+		favoredBranch, _ := a.favoredBranch(state, now, voter.ID())
+		state = state.WithVote(&Vote{Issuer: voter.ID(), BranchID: favoredBranch}, now)
+
+		if score := a.search(state, now.Add(voteInterval), honestVoters, a.lookahead-1); bestScore.less(score) {
+			bestBranch, bestScore = candidate, score
+		}
+	}
+
+	if bestBranch != UndefinedBranchID && bestBranch != lastStatement {
+		a.network.VoteReceived.Trigger(&Vote{
+			Issuer:   a.id,
+			BranchID: bestBranch,
+		})
+	}
+}
+
+func (a *AdaptiveAttacker) SendVote() (opinionChanged bool) {
+	// do nothing, we have our own voting strategy based on the behavior of others
+	return false
+}
+
+func (a *AdaptiveAttacker) Type() string {
+	return "AdaptiveAttacker"
+}
+
+// IsAttacker marks the AdaptiveAttacker as an Attacker.
+func (a *AdaptiveAttacker) IsAttacker() {}
+
+// candidates returns the possible votes of the attacker (the last statement is tried first so it wins ties).
+func (a *AdaptiveAttacker) candidates(snapshot *Snapshot, lastStatement BranchID) (candidates []BranchID) {
+	candidates = []BranchID{lastStatement}
+	for branchID := range snapshot.BranchIDs() {
+		if branchID != lastStatement {
+			candidates = append(candidates, branchID)
+		}
+	}
+
+	return candidates
+}
+
+// search returns the worst searchScore (from the perspective of the attacker) over all possible orderings of the next
+// depth honest votes that are cast starting at the given time.
+func (a *AdaptiveAttacker) search(state *Snapshot, now time.Time, honestVoters []Voter, depth int) (score searchScore) {
+	if a.resolved(state, honestVoters) {
+		return searchScore{}
+	}
+
+	if depth == 0 {
+		return searchScore{steps: 1, honestWeightGap: a.honestWeightGap(state, honestVoters)}
+	}
+
+	// honest Voters with the same weight and the same statement are interchangeable, so we only explore one of them
+	type voterClass struct {
+		weight    float64
+		statement BranchID
+	}
+	exploredClasses := make(map[voterClass]bool)
+
+	for _, honestVoter := range honestVoters {
+		favoredBranch, wantsToChangeOpinion := a.favoredBranch(state, now, honestVoter.ID())
+		if !wantsToChangeOpinion {
+			continue
+		}
+
+		statement, _ := state.LastStatement(honestVoter.ID())
+		class := voterClass{a.network.WeightDistribution.Weight(honestVoter.ID()), statement}
+		if exploredClasses[class] {
+			continue
+		}
+		exploredClasses[class] = true
+
+		nextState := state.WithVote(&Vote{Issuer: honestVoter.ID(), BranchID: favoredBranch}, now)
+		if nextScore := a.search(nextState, now.Add(voteInterval), honestVoters, depth-1).nextStep(); len(exploredClasses) == 1 || nextScore.less(score) {
+			score = nextScore
+		}
+	}
+
+	// if no honest Voter wants to change its opinion, we let the time pass
+	if len(exploredClasses) == 0 {
+		return a.search(state, now.Add(voteInterval), honestVoters, depth-1).nextStep()
+	}
+
+	return score
+}
+
+// favoredBranch returns the Branch that the given Voter would vote for in the given Snapshot and if this differs from
+// its last statement.
+func (a *AdaptiveAttacker) favoredBranch(snapshot *Snapshot, now time.Time, voterID VoterID) (favoredBranch BranchID, wantsToChangeOpinion bool) {
+	favoredBranch = a.consensus.FavoredBranchAt(snapshot, now)
+	lastStatement, _ := snapshot.LastStatement(voterID)
+
+	return favoredBranch, favoredBranch != lastStatement
+}
+
+// honestWeightGap returns the difference between the weight of the given Voters on the two Branches that most of them
+// vote for in the given Snapshot.
+func (a *AdaptiveAttacker) honestWeightGap(snapshot *Snapshot, voters []Voter) float64 {
+	weightByBranch := make(map[BranchID]float64)
+	for _, voter := range voters {
+		if statement, exists := snapshot.LastStatement(voter.ID()); exists {
+			weightByBranch[statement] += a.network.WeightDistribution.Weight(voter.ID())
+		}
+	}
+
+	var largestWeight, secondLargestWeight float64
+	for _, weight := range weightByBranch {
+		if weight >= largestWeight {
+			largestWeight, secondLargestWeight = weight, largestWeight
+		} else if weight > secondLargestWeight {
+			secondLargestWeight = weight
+		}
+	}
+
+	return largestWeight - secondLargestWeight
+}
+
+// resolved returns true if all given Voters vote for the same Branch in the given Snapshot.
+func (a *AdaptiveAttacker) resolved(snapshot *Snapshot, voters []Voter) bool {
+	var agreedBranch BranchID
+	for _, voter := range voters {
+		statement, exists := snapshot.LastStatement(voter.ID())
+		if !exists || (agreedBranch != UndefinedBranchID && statement != agreedBranch) {
+			return false
+		}
+
+		agreedBranch = statement
+	}
+
+	return true
+}
+
+// honestVoters returns the HonestVoters of the Network that are currently online.
+func (a *AdaptiveAttacker) honestVoters() (honestVoters []Voter) {
+	for _, voter := range a.network.Voters() {
+		if voter.Type() == "HonestVoter" && voter.Online() {
+			honestVoters = append(honestVoters, voter)
+		}
+	}
+
+	return honestVoters
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region searchScore //////////////////////////////////////////////////////////////////////////////////////////////////
+
+// searchScore rates a simulated future from the perspective of an attacker. A future is worse for the honest Voters the
+// more steps it takes them to agree and (if they do not agree within the search horizon) the more evenly their weight
+// is split between the two heaviest Branches.
+type searchScore struct {
+	steps           int
+	honestWeightGap float64
+}
+
+// nextStep returns the searchScore of the step that leads to this searchScore.
+func (s searchScore) nextStep() searchScore {
+	return searchScore{steps: s.steps + 1, honestWeightGap: s.honestWeightGap}
+}
+
+// less returns true if the other searchScore is better for the attacker.
+func (s searchScore) less(other searchScore) bool {
+	if s.steps != other.steps {
+		return s.steps < other.steps
+	}
+
+	return s.honestWeightGap > other.honestWeightGap
 }
 
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -279,22 +883,46 @@ type Voter interface {
 	Network() *Network
 	ApprovalWeightManager() *ApprovalWeightManager
 	BranchManager() *BranchManager
+	Online() bool
 	SendVote() (opinionChanged bool)
 	OnVoteReceived(vote *Vote)
 }
 
+// Attacker is the interface of the Voters that deliberately try to prevent the honest Voters from agreeing. All other
+// Voters (including the faulty and the noisy baseline Voters) count as honest in the WeightDistributionStats.
+type Attacker interface {
+	Voter
+
+	// IsAttacker marks the Voter as an Attacker.
+	IsAttacker()
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// region randomBranch /////////////////////////////////////////////////////////////////////////////////////////////////
+
+// randomBranch returns a uniformly random Branch of the given Branches (UndefinedBranchID if there are none).
+func randomBranch(random *rand.Rand, branchIDs BranchIDs) BranchID {
+	sortedBranchIDs := branchIDs.Sorted()
+	if len(sortedBranchIDs) == 0 {
+		return UndefinedBranchID
+	}
+
+	return sortedBranchIDs[random.Intn(len(sortedBranchIDs))]
+}
+
 // endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // region VoterID //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 type VoterID int
 
-var voterID VoterID
+// voterIDCounter is the last VoterID that was handed out (it is shared by all Networks, so simulations can run in
+// parallel).
+var voterIDCounter int64
 
 func NewVoterID() VoterID {
-	voterID++
-
-	return voterID
+	return VoterID(atomic.AddInt64(&voterIDCounter, 1))
 }
 
 func (v VoterID) String() string {
@@ -0,0 +1,157 @@
+package metastabilitybreaker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// region WeightGenerator //////////////////////////////////////////////////////////////////////////////////////////////
+
+// WeightGenerator represents a function that returns the weight of a newly added Voter.
+type WeightGenerator func(voterID VoterID) float64
+
+// FixedWeight returns a WeightGenerator that assigns the same given weight to every Voter.
+func FixedWeight(weight float64) WeightGenerator {
+	return func(voterID VoterID) float64 {
+		return weight
+	}
+}
+
+// UniformWeights returns a WeightGenerator that splits the totalWeight equally between the given amount of Voters.
+func UniformWeights(amount int, totalWeight float64) WeightGenerator {
+	weights := make([]float64, amount)
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	return SequentialWeights(NormalizeWeights(weights, totalWeight))
+}
+
+// ZipfWeights returns a WeightGenerator that splits the totalWeight between the given amount of Voters according to
+// Zipf's law with the exponent s (the i-th Voter receives a weight that is proportional to 1/i^s).
+func ZipfWeights(amount int, s float64, totalWeight float64) WeightGenerator {
+	weights := make([]float64, amount)
+	for i := range weights {
+		weights[i] = 1 / math.Pow(float64(i+1), s)
+	}
+
+	return SequentialWeights(NormalizeWeights(weights, totalWeight))
+}
+
+// ParetoWeights returns a WeightGenerator that splits the totalWeight between the given amount of Voters according to
+// a Pareto distribution with the shape parameter alpha. The weights are taken from the evenly spaced quantiles of the
+// distribution (heaviest first), so that the generated distribution is deterministic and runs stay reproducible.
+func ParetoWeights(amount int, alpha float64, totalWeight float64) WeightGenerator {
+	weights := make([]float64, amount)
+	for i := range weights {
+		weights[i] = math.Pow(quantile(i, amount), -1/alpha)
+	}
+
+	return SequentialWeights(NormalizeWeights(weights, totalWeight))
+}
+
+// ExponentialWeights returns a WeightGenerator that splits the totalWeight between the given amount of Voters according
+// to an exponential distribution with the rate lambda. The weights are taken from the evenly spaced quantiles of the
+// distribution (heaviest first), so that the generated distribution is deterministic and runs stay reproducible.
+func ExponentialWeights(amount int, lambda float64, totalWeight float64) WeightGenerator {
+	weights := make([]float64, amount)
+	for i := range weights {
+		weights[i] = -math.Log(quantile(i, amount)) / lambda
+	}
+
+	return SequentialWeights(NormalizeWeights(weights, totalWeight))
+}
+
+// CSVWeights reads a snapshot of real node weights from the given CSV and returns a WeightGenerator that splits the
+// totalWeight between the nodes proportionally to their weight. Every record is expected to contain the weight in its
+// last column and a header row is skipped if its weight column is not numeric. It additionally returns the amount of
+// nodes contained in the snapshot, so it can be passed to Network.AddVoters.
+func CSVWeights(reader io.Reader, totalWeight float64) (weightGenerator WeightGenerator, amount int, err error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	weights := make([]float64, 0, len(records))
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+
+		weight, parseErr := strconv.ParseFloat(strings.TrimSpace(record[len(record)-1]), 64)
+		if parseErr != nil {
+			if i == 0 {
+				continue
+			}
+
+			return nil, 0, fmt.Errorf("failed to parse weight in line %d: %w", i+1, parseErr)
+		}
+		if weight < 0 {
+			return nil, 0, fmt.Errorf("negative weight in line %d", i+1)
+		}
+
+		weights = append(weights, weight)
+	}
+
+	if len(weights) == 0 {
+		return nil, 0, fmt.Errorf("CSV does not contain any weights")
+	}
+
+	return SequentialWeights(NormalizeWeights(weights, totalWeight)), len(weights), nil
+}
+
+// SequentialWeights returns a WeightGenerator that assigns the given weights to the Voters in the order in which they
+// are added. Voters that are added after the weights were used up receive a weight of 0.
+func SequentialWeights(weights []float64) WeightGenerator {
+	var mutex sync.Mutex
+	var nextIndex int
+
+	return func(voterID VoterID) (weight float64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if nextIndex >= len(weights) {
+			return 0
+		}
+
+		weight = weights[nextIndex]
+		nextIndex++
+
+		return weight
+	}
+}
+
+// NormalizeWeights returns a copy of the given weights that is scaled so that they sum up to the totalWeight.
+func NormalizeWeights(weights []float64, totalWeight float64) (normalizedWeights []float64) {
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+
+	normalizedWeights = make([]float64, len(weights))
+	if sum == 0 {
+		return normalizedWeights
+	}
+
+	for i, weight := range weights {
+		normalizedWeights[i] = weight / sum * totalWeight
+	}
+
+	return normalizedWeights
+}
+
+// quantile returns the center of the i-th of amount evenly sized probability intervals.
+func quantile(i, amount int) float64 {
+	return (float64(i) + 0.5) / float64(amount)
+}
+
+// endregion ///////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,66 @@
+package metastabilitybreaker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightGenerators_Normalization(t *testing.T) {
+	for name, weightGenerator := range map[string]WeightGenerator{
+		"Uniform":     UniformWeights(10, 0.8),
+		"Zipf":        ZipfWeights(10, 1.2, 0.8),
+		"Pareto":      ParetoWeights(10, 1.16, 0.8),
+		"Exponential": ExponentialWeights(10, 2, 0.8),
+	} {
+		weights := generateWeights(10, weightGenerator)
+
+		var totalWeight float64
+		for i, weight := range weights {
+			totalWeight += weight
+
+			if i > 0 {
+				assert.LessOrEqual(t, weight, weights[i-1], "%s weights should be sorted heaviest first", name)
+			}
+		}
+		assert.InDelta(t, 0.8, totalWeight, 1e-9, "%s weights should sum up to the total weight", name)
+		assert.Equal(t, float64(0), weightGenerator(NewVoterID()), "%s should return 0 once the weights are used up", name)
+	}
+}
+
+func TestZipfWeights(t *testing.T) {
+	weights := generateWeights(3, ZipfWeights(3, 1, 1))
+
+	assert.InDelta(t, 2*weights[1], weights[0], 1e-9)
+	assert.InDelta(t, 3*weights[2], weights[0], 1e-9)
+}
+
+func TestCSVWeights(t *testing.T) {
+	weightGenerator, amount, err := CSVWeights(strings.NewReader("node,weight\nnodeA,30\nnodeB,10\n"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, amount)
+	assert.Equal(t, []float64{0.75, 0.25}, generateWeights(amount, weightGenerator))
+
+	_, _, err = CSVWeights(strings.NewReader("nodeA,30\nnodeB,abc\n"), 1)
+	assert.Error(t, err)
+}
+
+func TestWeightDistribution_Normalize(t *testing.T) {
+	network := NewNetwork(0)
+	network.AddVoters(3, NewHonestVoter, FixedWeight(2))
+	network.AddVoters(1, NewMinorityVoter, FixedWeight(4))
+	network.WeightDistribution.Normalize()
+
+	assert.InDelta(t, 1, network.WeightDistribution.TotalWeight(), 1e-9)
+}
+
+func generateWeights(amount int, weightGenerator WeightGenerator) (weights []float64) {
+	weights = make([]float64, amount)
+	for i := range weights {
+		weights[i] = weightGenerator(NewVoterID())
+	}
+
+	return weights
+}